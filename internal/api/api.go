@@ -14,11 +14,31 @@ func NewRouter(
 	authHandler *AuthHandler,
 	subscriptionHandler *SubscriptionHandler,
 	userHandler *UserHandler,
+	channelHandler *ChannelHandler,
+	telegramHandler *TelegramHandler,
+	chatWebhookHandler *ChatWebhookHandler,
+	webhookHandler *WebhookHandler,
+	pushHandler *PushHandler,
+	inboxHandler *InboxHandler,
+	unsubscribeHandler *UnsubscribeHandler,
+	reminderHandler *ReminderHandler,
+	settingsHandler *SettingsHandler,
+	birthdayHandler *BirthdayHandler,
 ) http.Handler {
 	r := chi.NewRouter()
 	r.Mount("/auth", authHandler.router)
 	r.Mount("/api/subscription", subscriptionHandler.router)
 	r.Mount("/api/users", userHandler.router)
+	r.Mount("/api/channels", channelHandler.router)
+	r.Mount("/api/telegram", telegramHandler.router)
+	r.Mount("/api/chat-webhooks", chatWebhookHandler.router)
+	r.Mount("/api/webhooks", webhookHandler.router)
+	r.Mount("/api/push", pushHandler.router)
+	r.Mount("/api/notifications", inboxHandler.router)
+	r.Mount("/api/unsubscribe", unsubscribeHandler.router)
+	r.Mount("/api/reminders", reminderHandler.router)
+	r.Mount("/api/settings", settingsHandler.router)
+	r.Mount("/api/birthdays", birthdayHandler.router)
 	r.Handle("/docs/*", http.StripPrefix("/docs/", initDocsFilesServer()))
 	return r
 }
@@ -10,14 +10,21 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
 	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
 )
 
+var invalidVerificationLinkPage = linkPage{
+	Title:   "Invalid link",
+	Message: "This email verification link is invalid or has expired.",
+}
+
 type AuthHandler struct {
-	authService service.AuthService
-	validate    *validator.Validate
-	router      chi.Router
+	authService    service.AuthService
+	authMiddleware middleware.AuthMiddleware
+	validate       *validator.Validate
+	router         chi.Router
 }
 
 type signUpRequestBody struct {
@@ -26,6 +33,8 @@ type signUpRequestBody struct {
 	Name         string `json:"name" validate:"required,min=1"`
 	Surname      string `json:"surname" validate:"required,min=1"`
 	BirthdayDate string `json:"birthday_date" validate:"required"`
+	// Consent to personal data processing, its time is recorded on signup
+	Consent *bool `json:"consent" validate:"required"`
 }
 
 type signInRequestBody struct {
@@ -33,11 +42,30 @@ type signInRequestBody struct {
 	Password string `json:"password" validate:"required,min=7"`
 }
 
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
+type forgotPasswordRequestBody struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type resetPasswordRequestBody struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=7"`
+}
+
+type changePasswordRequestBody struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=7"`
+}
+
+type refreshRequestBody struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func NewAuthHandler(authService service.AuthService, authMiddleware middleware.AuthMiddleware) *AuthHandler {
 	handler := &AuthHandler{
-		authService: authService,
-		validate:    validatorext.NewValidator(),
-		router:      chi.NewRouter(),
+		authService:    authService,
+		authMiddleware: authMiddleware,
+		validate:       validatorext.NewValidator(),
+		router:         chi.NewRouter(),
 	}
 	handler.initRoutes()
 	return handler
@@ -67,6 +95,11 @@ func (h *AuthHandler) signUp(w http.ResponseWriter, r *http.Request) {
 		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
 		return
 	}
+	if !*body.Consent {
+		errText := fmt.Errorf("consent to personal data processing is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
 
 	birthdayDate, err := time.Parse(time.DateOnly, body.BirthdayDate)
 	if err != nil {
@@ -147,7 +180,284 @@ func (h *AuthHandler) signIn(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(response)
 }
 
+func (h *AuthHandler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err := h.authService.ResendVerification(r.Context(), userId)
+	if errors.Is(err, service.ErrEmailAlreadyVerified) {
+		WriteErrorResponse(w, http.StatusConflict, err)
+		return
+	}
+	if errors.Is(err, service.ErrVerificationTooFrequent) {
+		WriteErrorResponse(w, http.StatusTooManyRequests, err)
+		return
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("verification email sent"))
+}
+
+// getVerificationPage does not change anything, since links in emails
+// are often opened by mail scanners without user's intention
+func (h *AuthHandler) getVerificationPage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if err := h.authService.CheckVerificationToken(r.Context(), token); err != nil {
+		writeLinkPage(w, http.StatusBadRequest, invalidVerificationLinkPage)
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Verify email",
+		Message: "Receive birthday reminders at this address?",
+		Actions: []linkPageAction{
+			{Name: "verify", Value: "true", Label: "Verify email"},
+		},
+	})
+}
+
+func (h *AuthHandler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	err := h.authService.VerifyEmail(r.Context(), token)
+	if errors.Is(err, service.ErrInvalidLinkToken) {
+		writeLinkPage(w, http.StatusBadRequest, invalidVerificationLinkPage)
+		return
+	}
+	if err != nil {
+		writeLinkPage(w, http.StatusInternalServerError, linkPage{
+			Title:   "Something went wrong",
+			Message: "Failed to verify email, please try again later.",
+		})
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Email verified",
+		Message: "You will now receive birthday reminders at this address.",
+	})
+}
+
+func (h *AuthHandler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body forgotPasswordRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	err = h.authService.ForgotPassword(r.Context(), body.Email)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("if the email is registered and verified, password reset token was sent to it"))
+}
+
+func (h *AuthHandler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body resetPasswordRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	err = h.authService.ResetPassword(r.Context(), body.Token, body.Password)
+	if errors.Is(err, service.ErrInvalidResetToken) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("update result unknown. try to sign in with the new password")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("password reset, please sign in"))
+}
+
+func (h *AuthHandler) changePassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body changePasswordRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	token, err := h.authService.ChangePassword(r.Context(), userId, body.OldPassword, body.NewPassword)
+	if errors.Is(err, service.ErrInvalidPassword) {
+		errText := fmt.Errorf("invalid password")
+		WriteErrorResponse(w, http.StatusUnauthorized, errText)
+		return
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("update result unknown. try to sign in with the new password")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(token)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body refreshRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	token, err := h.authService.Refresh(r.Context(), body.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+		WriteErrorResponse(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(token)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	tokenId := r.Context().Value(h.authMiddleware.GetTokenIdContextKey()).(string)
+	err := h.authService.Logout(r.Context(), tokenId)
+	if errors.Is(err, service.ErrTokenRevoked) {
+		errText := fmt.Errorf("session is already revoked")
+		WriteErrorResponse(w, http.StatusUnauthorized, errText)
+		return
+	}
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("logout result unknown. try to log out again")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("logged out"))
+}
+
 func (h *AuthHandler) initRoutes() {
 	h.router.Post("/signup", h.signUp)
 	h.router.Post("/signin", h.signIn)
+	h.router.Post("/refresh", h.refresh)
+	h.router.With(h.authMiddleware.Auth).Post("/logout", h.logout)
+	h.router.Get("/email/verify", h.getVerificationPage)
+	h.router.Post("/email/verify", h.verifyEmail)
+	h.router.With(h.authMiddleware.Auth).Post("/email/verify/resend", h.resendVerification)
+	h.router.Post("/password/forgot", h.forgotPassword)
+	h.router.Post("/password/reset", h.resetPassword)
+	h.router.With(h.authMiddleware.Auth).Post("/password/change", h.changePassword)
 }
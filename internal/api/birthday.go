@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+const (
+	defaultUpcomingDays = 30
+	maxUpcomingDays     = 366
+	minCalendarYear     = 1900
+	maxCalendarYear     = 9999
+)
+
+type BirthdayHandler struct {
+	birthdayService service.BirthdayService
+	authMiddleware  middleware.AuthMiddleware
+	validate        *validator.Validate
+	router          chi.Router
+}
+
+type calendarResponseBody struct {
+	Year  int                 `json:"year"`
+	Month int                 `json:"month"`
+	Days  []model.CalendarDay `json:"days"`
+}
+
+func NewBirthdayHandler(
+	birthdayService service.BirthdayService,
+	authMiddleware middleware.AuthMiddleware,
+) *BirthdayHandler {
+	handler := &BirthdayHandler{
+		birthdayService: birthdayService,
+		authMiddleware:  authMiddleware,
+		validate:        validatorext.NewValidator(),
+		router:          chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *BirthdayHandler) getUpcomingBirthdays(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	days := defaultUpcomingDays
+	if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+		parsedDays, err := strconv.Atoi(daysParam)
+		if err != nil || parsedDays < 0 || parsedDays > maxUpcomingDays {
+			errText := fmt.Errorf("days must be a number between 0 and %d", maxUpcomingDays)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		days = parsedDays
+	}
+
+	scope, ok := parseBirthdayScope(r)
+	if !ok {
+		errText := fmt.Errorf("scope must be one of: subscribed, all")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	birthdays, err := h.birthdayService.FindUpcomingBirthdays(r.Context(), userId, scope, days)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	// no birthdays in the window is a regular result, not a missing resource
+	if birthdays == nil {
+		birthdays = []model.UserBirthday{}
+	}
+
+	response, err := json.Marshal(birthdays)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *BirthdayHandler) getCalendar(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	now := time.Now()
+	year := now.Year()
+	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
+		parsedYear, err := strconv.Atoi(yearParam)
+		if err != nil || parsedYear < minCalendarYear || parsedYear > maxCalendarYear {
+			errText := fmt.Errorf("year must be a number between %d and %d", minCalendarYear, maxCalendarYear)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		year = parsedYear
+	}
+	month := int(now.Month())
+	if monthParam := r.URL.Query().Get("month"); monthParam != "" {
+		parsedMonth, err := strconv.Atoi(monthParam)
+		if err != nil || parsedMonth < 1 || parsedMonth > 12 {
+			errText := fmt.Errorf("month must be a number between 1 and 12")
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		month = parsedMonth
+	}
+
+	scope, ok := parseBirthdayScope(r)
+	if !ok {
+		errText := fmt.Errorf("scope must be one of: subscribed, all")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	days, err := h.birthdayService.FindCalendar(r.Context(), userId, scope, year, month)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(calendarResponseBody{Year: year, Month: month, Days: days})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func parseBirthdayScope(r *http.Request) (string, bool) {
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		return model.BirthdayScopeSubscribed, true
+	}
+	return scope, scope == model.BirthdayScopeSubscribed || scope == model.BirthdayScopeAll
+}
+
+func (h *BirthdayHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Get("/upcoming", h.getUpcomingBirthdays)
+	h.router.With(h.authMiddleware.Auth).Get("/calendar", h.getCalendar)
+}
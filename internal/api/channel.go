@@ -0,0 +1,331 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+// validation tags applied to the address depending on the channel it belongs to
+var channelAddressTags = map[string]string{
+	model.ChannelEmail:   "email",
+	model.ChannelSms:     "e164",
+	model.ChannelWebPush: "uuid4",
+}
+
+type ChannelHandler struct {
+	channelService service.ChannelService
+	authMiddleware middleware.AuthMiddleware
+	validate       *validator.Validate
+	router         chi.Router
+}
+
+// telegram chat is bound only by linking the account with the bot, so it cannot be saved directly
+type saveChannelRequestBody struct {
+	Channel string `json:"channel" validate:"required,oneof=email sms exec"`
+	Address string `json:"address" validate:"required,max=1024"`
+	Enabled *bool  `json:"enabled" validate:"required"`
+}
+
+type deleteChannelRequestBody struct {
+	Channel string `json:"channel" validate:"required,oneof=email telegram sms exec"`
+}
+
+type saveSubscriptionChannelRequestBody struct {
+	UserId  string  `json:"user_id" validate:"required,uuid4"`
+	Channel string  `json:"channel" validate:"required,oneof=email telegram sms webpush exec"`
+	Address *string `json:"address" validate:"omitempty,max=1024"`
+	Enabled *bool   `json:"enabled" validate:"required"`
+}
+
+type deleteSubscriptionChannelRequestBody struct {
+	UserId  string `json:"user_id" validate:"required,uuid4"`
+	Channel string `json:"channel" validate:"required,oneof=email telegram sms webpush exec"`
+}
+
+func NewChannelHandler(
+	channelService service.ChannelService,
+	authMiddleware middleware.AuthMiddleware,
+) *ChannelHandler {
+	handler := &ChannelHandler{
+		channelService: channelService,
+		authMiddleware: authMiddleware,
+		validate:       validatorext.NewValidator(),
+		router:         chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *ChannelHandler) validateAddress(channel, address string) error {
+	tag, ok := channelAddressTags[channel]
+	if !ok {
+		return nil
+	}
+	if err := h.validate.Var(address, tag); err != nil {
+		return fmt.Errorf("address is not valid for channel %s", channel)
+	}
+	return nil
+}
+
+func (h *ChannelHandler) getChannels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	channels, err := h.channelService.FindUserChannels(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(channels)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *ChannelHandler) saveChannel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body saveChannelRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+	if err := h.validateAddress(body.Channel, body.Address); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.channelService.SaveUserChannel(r.Context(), userId, body.Channel, body.Address, *body.Enabled)
+	if errors.Is(err, service.ErrUnverifiedEmailAddress) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("channel saved"))
+}
+
+func (h *ChannelHandler) deleteChannel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deleteChannelRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.channelService.DeleteUserChannel(r.Context(), userId, body.Channel)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your channels and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrChannelNotFound) {
+		errText := fmt.Errorf("channel you are trying to delete was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("channel deleted"))
+}
+
+func (h *ChannelHandler) getSubscriptionChannels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := chi.URLParam(r, "userId")
+	if err := h.validate.Var(userId, "uuid4"); err != nil {
+		errText := fmt.Errorf("user id must be UUIDv4")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	channels, err := h.channelService.FindSubscriptionChannels(r.Context(), userId, subscriberId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(channels)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *ChannelHandler) saveSubscriptionChannel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body saveSubscriptionChannelRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+	if body.Address != nil && body.Channel == model.ChannelTelegram {
+		errText := fmt.Errorf("telegram address cannot be overridden, it is set by linking the account")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if body.Address != nil {
+		if err := h.validateAddress(body.Channel, *body.Address); err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.channelService.SaveSubscriptionChannel(
+		r.Context(), body.UserId, subscriberId, body.Channel, body.Address, *body.Enabled,
+	)
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		errText := fmt.Errorf("subscription you are trying to configure was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if errors.Is(err, service.ErrUnverifiedEmailAddress) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, service.ErrPushSubscriptionNotFound) {
+		errText := fmt.Errorf("push subscription was not found among your subscriptions")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("subscription channel saved"))
+}
+
+func (h *ChannelHandler) deleteSubscriptionChannel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deleteSubscriptionChannelRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.channelService.DeleteSubscriptionChannel(r.Context(), body.UserId, subscriberId, body.Channel)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your channels and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrChannelNotFound) {
+		errText := fmt.Errorf("subscription channel you are trying to delete was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("subscription channel deleted"))
+}
+
+func (h *ChannelHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Get("/", h.getChannels)
+	h.router.With(h.authMiddleware.Auth).Put("/", h.saveChannel)
+	h.router.With(h.authMiddleware.Auth).Delete("/", h.deleteChannel)
+	h.router.With(h.authMiddleware.Auth).Get("/subscription/{userId}", h.getSubscriptionChannels)
+	h.router.With(h.authMiddleware.Auth).Put("/subscription", h.saveSubscriptionChannel)
+	h.router.With(h.authMiddleware.Auth).Delete("/subscription", h.deleteSubscriptionChannel)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+type ChatWebhookHandler struct {
+	chatWebhookService service.ChatWebhookService
+	authMiddleware     middleware.AuthMiddleware
+	validate           *validator.Validate
+	router             chi.Router
+}
+
+type createChatWebhookRequestBody struct {
+	Name             string `json:"name" validate:"required,min=1,max=255"`
+	Url              string `json:"url" validate:"required,url,max=1024"`
+	Format           string `json:"format" validate:"required,oneof=slack mattermost"`
+	AnnounceToday    *bool  `json:"announce_today" validate:"required"`
+	RemindBeforeDays int    `json:"remind_before_days" validate:"min=0,max=7"`
+}
+
+type deleteChatWebhookRequestBody struct {
+	Id string `json:"id" validate:"required,uuid4"`
+}
+
+func NewChatWebhookHandler(
+	chatWebhookService service.ChatWebhookService,
+	authMiddleware middleware.AuthMiddleware,
+) *ChatWebhookHandler {
+	handler := &ChatWebhookHandler{
+		chatWebhookService: chatWebhookService,
+		authMiddleware:     authMiddleware,
+		validate:           validatorext.NewValidator(),
+		router:             chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *ChatWebhookHandler) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	webhooks, err := h.chatWebhookService.FindWebhooks(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(webhooks)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *ChatWebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body createChatWebhookRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	webhook, err := h.chatWebhookService.CreateWebhook(r.Context(), model.ChatWebhook{
+		OwnerId:          userId,
+		Name:             body.Name,
+		Url:              body.Url,
+		Format:           body.Format,
+		AnnounceToday:    *body.AnnounceToday,
+		RemindBeforeDays: body.RemindBeforeDays,
+		Enabled:          true,
+	})
+	if errors.Is(err, service.ErrInvalidChatWebhookUrl) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(webhook)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func (h *ChatWebhookHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deleteChatWebhookRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.chatWebhookService.DeleteWebhook(r.Context(), body.Id, userId)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your chat webhooks and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrChatWebhookNotFound) {
+		errText := fmt.Errorf("chat webhook you are trying to delete was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("chat webhook deleted"))
+}
+
+func (h *ChatWebhookHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Get("/", h.getWebhooks)
+	h.router.With(h.authMiddleware.Auth).Post("/", h.createWebhook)
+	h.router.With(h.authMiddleware.Auth).Delete("/", h.deleteWebhook)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+const (
+	defaultInboxLimit = 20
+	maxInboxLimit     = 100
+)
+
+type InboxHandler struct {
+	inboxService   service.InboxService
+	authMiddleware middleware.AuthMiddleware
+	validate       *validator.Validate
+	router         chi.Router
+}
+
+type inboxPageResponseBody struct {
+	Notifications []model.InboxNotification `json:"notifications"`
+	NextCursor    *string                   `json:"next_cursor"`
+}
+
+type unreadCountResponseBody struct {
+	UnreadCount int `json:"unread_count"`
+}
+
+type markReadResponseBody struct {
+	Updated int64 `json:"updated"`
+}
+
+type markReadRequestBody struct {
+	Ids []string `json:"ids" validate:"required,min=1,max=500,dive,uuid4"`
+}
+
+func NewInboxHandler(
+	inboxService service.InboxService,
+	authMiddleware middleware.AuthMiddleware,
+) *InboxHandler {
+	handler := &InboxHandler{
+		inboxService:   inboxService,
+		authMiddleware: authMiddleware,
+		validate:       validatorext.NewValidator(),
+		router:         chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *InboxHandler) getNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	cursor := r.URL.Query().Get("cursor")
+	if cursor != "" {
+		if err := h.validate.Var(cursor, "uuid4"); err != nil {
+			errText := fmt.Errorf("cursor must be UUIDv4")
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+	}
+
+	unreadOnly := false
+	if unreadParam := r.URL.Query().Get("unread"); unreadParam != "" {
+		parsedUnread, err := strconv.ParseBool(unreadParam)
+		if err != nil {
+			errText := fmt.Errorf("unread must be a boolean")
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		unreadOnly = parsedUnread
+	}
+
+	limit := defaultInboxLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxInboxLimit {
+			errText := fmt.Errorf("limit must be a number between 1 and %d", maxInboxLimit)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	notifications, nextCursor, err := h.inboxService.FindNotifications(r.Context(), userId, cursor, unreadOnly, limit)
+	if errors.Is(err, service.ErrInvalidInboxCursor) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page := inboxPageResponseBody{Notifications: notifications}
+	if page.Notifications == nil {
+		page.Notifications = []model.InboxNotification{}
+	}
+	if nextCursor != "" {
+		page.NextCursor = &nextCursor
+	}
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *InboxHandler) getUnreadCount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	count, err := h.inboxService.CountUnread(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(unreadCountResponseBody{UnreadCount: count})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *InboxHandler) markRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	notificationId := chi.URLParam(r, "notificationId")
+	if err := h.validate.Var(notificationId, "uuid4"); err != nil {
+		errText := fmt.Errorf("notification id must be UUIDv4")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err := h.inboxService.MarkRead(r.Context(), userId, notificationId)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("update result unknown. check your notifications and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrInboxNotificationNotFound) {
+		errText := fmt.Errorf("notification you are trying to mark as read was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("notification marked as read"))
+}
+
+func (h *InboxHandler) markManyRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body markReadRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	count, err := h.inboxService.MarkManyRead(r.Context(), userId, body.Ids)
+	h.writeMarkReadResult(w, count, err)
+}
+
+func (h *InboxHandler) markAllRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	count, err := h.inboxService.MarkAllRead(r.Context(), userId)
+	h.writeMarkReadResult(w, count, err)
+}
+
+func (h *InboxHandler) writeMarkReadResult(w http.ResponseWriter, count int64, err error) {
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("update result unknown. check your notifications and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(markReadResponseBody{Updated: count})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *InboxHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Get("/", h.getNotifications)
+	h.router.With(h.authMiddleware.Auth).Get("/unread-count", h.getUnreadCount)
+	h.router.With(h.authMiddleware.Auth).Post("/read", h.markManyRead)
+	h.router.With(h.authMiddleware.Auth).Post("/read-all", h.markAllRead)
+	h.router.With(h.authMiddleware.Auth).Post("/{notificationId}/read", h.markRead)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// linkPageTemplate renders pages opened from the links in notifications,
+// form is posted back to the same url, so the token stays in the query
+var linkPageTemplate = template.Must(template.New("link_page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{range .Actions}}<form method="post">
+<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+<button type="submit">{{.Label}}</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+type linkPage struct {
+	Title   string
+	Message string
+	Actions []linkPageAction
+}
+
+type linkPageAction struct {
+	Name  string
+	Value string
+	Label string
+}
+
+func writeLinkPage(w http.ResponseWriter, statusCode int, page linkPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// links carry tokens, they should not leak to other sites through referer
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(statusCode)
+	_ = linkPageTemplate.Execute(w, page)
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+type PushHandler struct {
+	pushService    service.PushService
+	authMiddleware middleware.AuthMiddleware
+	vapidPublicKey string
+	validate       *validator.Validate
+	router         chi.Router
+}
+
+type vapidPublicKeyResponseBody struct {
+	PublicKey string `json:"public_key"`
+}
+
+type pushSubscriptionKeys struct {
+	P256dh string `json:"p256dh" validate:"required,max=255"`
+	Auth   string `json:"auth" validate:"required,max=255"`
+}
+
+type savePushSubscriptionRequestBody struct {
+	Endpoint string               `json:"endpoint" validate:"required,url,startswith=https://,max=2048"`
+	Keys     pushSubscriptionKeys `json:"keys" validate:"required"`
+}
+
+type deletePushSubscriptionRequestBody struct {
+	Endpoint string `json:"endpoint" validate:"required,url,max=2048"`
+}
+
+func NewPushHandler(
+	pushService service.PushService,
+	authMiddleware middleware.AuthMiddleware,
+	vapidPublicKey string,
+) *PushHandler {
+	handler := &PushHandler{
+		pushService:    pushService,
+		authMiddleware: authMiddleware,
+		vapidPublicKey: vapidPublicKey,
+		validate:       validatorext.NewValidator(),
+		router:         chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *PushHandler) getVapidPublicKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	// push is disabled when no key pair is configured
+	if h.vapidPublicKey == "" {
+		errText := fmt.Errorf("web push is not configured")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+
+	response, err := json.Marshal(vapidPublicKeyResponseBody{PublicKey: h.vapidPublicKey})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *PushHandler) saveSubscription(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body savePushSubscriptionRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	subscription, err := h.pushService.SaveSubscription(
+		r.Context(), userId, body.Endpoint, body.Keys.P256dh, body.Keys.Auth,
+	)
+	if errors.Is(err, service.ErrInvalidPushKeys) {
+		errText := fmt.Errorf("p256dh or auth key is malformed")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(subscription)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func (h *PushHandler) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deletePushSubscriptionRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.pushService.DeleteSubscription(r.Context(), userId, body.Endpoint)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your subscriptions and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrPushSubscriptionNotFound) {
+		errText := fmt.Errorf("push subscription you are trying to delete was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("push subscription deleted"))
+}
+
+func (h *PushHandler) initRoutes() {
+	h.router.Get("/vapid-public-key", h.getVapidPublicKey)
+	h.router.With(h.authMiddleware.Auth).Post("/subscriptions", h.saveSubscription)
+	h.router.With(h.authMiddleware.Auth).Delete("/subscriptions", h.deleteSubscription)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+var invalidReminderLinkPage = linkPage{
+	Title:   "Invalid link",
+	Message: "This link is invalid or has expired.",
+}
+
+type ReminderHandler struct {
+	reminderService service.ReminderService
+	router          chi.Router
+}
+
+func NewReminderHandler(reminderService service.ReminderService) *ReminderHandler {
+	handler := &ReminderHandler{
+		reminderService: reminderService,
+		router:          chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+// confirmation pages are shown on GET, since links in emails
+// are often opened by mail scanners without user's intention
+func (h *ReminderHandler) getSnoozePage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if err := h.reminderService.CheckToken(r.Context(), model.LinkActionSnooze, token); err != nil {
+		writeLinkPage(w, http.StatusBadRequest, invalidReminderLinkPage)
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Snooze reminder",
+		Message: "Send this reminder again tomorrow?",
+		Actions: []linkPageAction{{Name: "confirm", Value: "true", Label: "Remind me tomorrow"}},
+	})
+}
+
+func (h *ReminderHandler) snooze(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	err := h.reminderService.Snooze(r.Context(), token)
+	if errors.Is(err, service.ErrInvalidLinkToken) {
+		writeLinkPage(w, http.StatusBadRequest, invalidReminderLinkPage)
+		return
+	}
+	if errors.Is(err, service.ErrSnoozeTooLate) {
+		writeLinkPage(w, http.StatusBadRequest, linkPage{
+			Title:   "Too late to snooze",
+			Message: "The birthday is today or has already passed.",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		writeLinkPage(w, http.StatusNotFound, linkPage{
+			Title:   "Subscription not found",
+			Message: "You are no longer subscribed to this person.",
+		})
+		return
+	}
+	if err != nil {
+		writeLinkPage(w, http.StatusInternalServerError, linkPage{
+			Title:   "Something went wrong",
+			Message: "Failed to snooze the reminder, please try again later.",
+		})
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Reminder snoozed",
+		Message: "You will be reminded again tomorrow.",
+	})
+}
+
+func (h *ReminderHandler) getCongratulatedPage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if err := h.reminderService.CheckToken(r.Context(), model.LinkActionCongratulated, token); err != nil {
+		writeLinkPage(w, http.StatusBadRequest, invalidReminderLinkPage)
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Already congratulated",
+		Message: "Stop reminders about this birthday until next year?",
+		Actions: []linkPageAction{{Name: "confirm", Value: "true", Label: "I've already congratulated"}},
+	})
+}
+
+func (h *ReminderHandler) markCongratulated(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	err := h.reminderService.MarkCongratulated(r.Context(), token)
+	if errors.Is(err, service.ErrInvalidLinkToken) {
+		writeLinkPage(w, http.StatusBadRequest, invalidReminderLinkPage)
+		return
+	}
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		writeLinkPage(w, http.StatusNotFound, linkPage{
+			Title:   "Subscription not found",
+			Message: "You are no longer subscribed to this person.",
+		})
+		return
+	}
+	if err != nil {
+		writeLinkPage(w, http.StatusInternalServerError, linkPage{
+			Title:   "Something went wrong",
+			Message: "Failed to save your choice, please try again later.",
+		})
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Got it",
+		Message: "You will not be reminded about this birthday again this year.",
+	})
+}
+
+func (h *ReminderHandler) initRoutes() {
+	h.router.Get("/snooze", h.getSnoozePage)
+	h.router.Post("/snooze", h.snooze)
+	h.router.Get("/congratulated", h.getCongratulatedPage)
+	h.router.Post("/congratulated", h.markCongratulated)
+}
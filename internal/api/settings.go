@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+type SettingsHandler struct {
+	settingsService service.SettingsService
+	authMiddleware  middleware.AuthMiddleware
+	validate        *validator.Validate
+	router          chi.Router
+}
+
+type saveVacationRequestBody struct {
+	From       string  `json:"from" validate:"required"`
+	Until      string  `json:"until" validate:"required"`
+	DelegateId *string `json:"delegate_id" validate:"omitempty,uuid4"`
+}
+
+type savePrivacyRequestBody struct {
+	HideSubscriptions    bool `json:"hide_subscriptions"`
+	NotifyNewSubscribers bool `json:"notify_new_subscribers"`
+	HideAge              bool `json:"hide_age"`
+}
+
+func NewSettingsHandler(
+	settingsService service.SettingsService,
+	authMiddleware middleware.AuthMiddleware,
+) *SettingsHandler {
+	handler := &SettingsHandler{
+		settingsService: settingsService,
+		authMiddleware:  authMiddleware,
+		validate:        validatorext.NewValidator(),
+		router:          chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *SettingsHandler) getVacation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	vacation, err := h.settingsService.FindVacation(r.Context(), userId)
+	if errors.Is(err, service.ErrVacationNotFound) {
+		errText := fmt.Errorf("vacation is not set")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(vacation)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SettingsHandler) saveVacation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body saveVacationRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	from, err := time.Parse(time.DateOnly, body.From)
+	if err != nil {
+		errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	until, err := time.Parse(time.DateOnly, body.Until)
+	if err != nil {
+		errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	vacation, err := h.settingsService.SaveVacation(r.Context(), userId, from, until, body.DelegateId)
+	if errors.Is(err, service.ErrInvalidVacationPeriod) || errors.Is(err, service.ErrInvalidDelegate) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("delegate was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(vacation)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SettingsHandler) deleteVacation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err := h.settingsService.DeleteVacation(r.Context(), userId)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your vacation and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrVacationNotFound) {
+		errText := fmt.Errorf("vacation is not set")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("vacation deleted"))
+}
+
+func (h *SettingsHandler) getPrivacy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	privacy, err := h.settingsService.FindPrivacy(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(privacy)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SettingsHandler) savePrivacy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body savePrivacyRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	privacy := model.Privacy{
+		HideSubscriptions:    body.HideSubscriptions,
+		NotifyNewSubscribers: body.NotifyNewSubscribers,
+		HideAge:              body.HideAge,
+	}
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.settingsService.SavePrivacy(r.Context(), userId, privacy)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(privacy)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SettingsHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Get("/vacation", h.getVacation)
+	h.router.With(h.authMiddleware.Auth).Put("/vacation", h.saveVacation)
+	h.router.With(h.authMiddleware.Auth).Delete("/vacation", h.deleteVacation)
+	h.router.With(h.authMiddleware.Auth).Get("/privacy", h.getPrivacy)
+	h.router.With(h.authMiddleware.Auth).Put("/privacy", h.savePrivacy)
+}
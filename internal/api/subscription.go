@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
 	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
 	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
 )
@@ -22,10 +24,65 @@ type SubscriptionHandler struct {
 }
 
 type createSubscriptionRequestBody struct {
-	UserId           string `json:"user_id" validate:"required,uuid4"`
+	UserId           string  `json:"user_id" validate:"required,uuid4"`
+	NotifyBeforeDays int     `json:"notify_before_days" validate:"required,min=1,max=7"`
+	MutedUntil       *string `json:"muted_until"`
+}
+
+type muteSubscriptionRequestBody struct {
+	UserId     string `json:"user_id" validate:"required,uuid4"`
+	MutedUntil string `json:"muted_until" validate:"required"`
+}
+
+type unmuteSubscriptionRequestBody struct {
+	UserId string `json:"user_id" validate:"required,uuid4"`
+}
+
+// batch operations are best effort by default,
+// transactional ones are rolled back entirely if any item fails
+const batchModeTransactional = "transactional"
+
+type batchSubscriptionItem struct {
+	UserId           string  `json:"user_id" validate:"required,uuid4"`
+	NotifyBeforeDays int     `json:"notify_before_days" validate:"required,min=1,max=7"`
+	MutedUntil       *string `json:"muted_until"`
+}
+
+type createSubscriptionsRequestBody struct {
+	Subscriptions []batchSubscriptionItem `json:"subscriptions" validate:"required,min=1,max=100,dive"`
+	Mode          string                  `json:"mode" validate:"omitempty,oneof=transactional best_effort"`
+}
+
+type deleteSubscriptionsRequestBody struct {
+	UserIds []string `json:"user_ids" validate:"required,min=1,max=100,dive,uuid4"`
+	Mode    string   `json:"mode" validate:"omitempty,oneof=transactional best_effort"`
+}
+
+type batchSubscriptionResponse struct {
+	Results []model.SubscriptionResult `json:"results"`
+}
+
+type saveSubscriptionRuleRequestBody struct {
+	Rule             string `json:"rule" validate:"required,oneof=everyone department office"`
 	NotifyBeforeDays int    `json:"notify_before_days" validate:"required,min=1,max=7"`
 }
 
+type updateSubscriptionRequestBody struct {
+	NotifyBeforeDays *int          `json:"notify_before_days" validate:"omitempty,min=1,max=7"`
+	MutedUntil       optionalField `json:"muted_until"`
+}
+
+// optionalField tells a field missing from the request body from one explicitly set to null
+type optionalField struct {
+	Set   bool
+	Value *string
+}
+
+func (f *optionalField) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	return json.Unmarshal(data, &f.Value)
+}
+
 type deleteSubscriptionRequestBody struct {
 	UserId string `json:"user_id" validate:"required,uuid4"`
 }
@@ -69,9 +126,26 @@ func (h *SubscriptionHandler) createSubscription(w http.ResponseWriter, r *http.
 		return
 	}
 
+	var mutedUntil *time.Time
+	if body.MutedUntil != nil {
+		date, err := time.Parse(time.DateOnly, *body.MutedUntil)
+		if err != nil {
+			errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		mutedUntil = &date
+	}
+
 	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
 
-	err = h.subscriptionService.CreateSubscription(r.Context(), body.UserId, subscriberId, body.NotifyBeforeDays)
+	err = h.subscriptionService.CreateSubscription(
+		r.Context(), body.UserId, subscriberId, body.NotifyBeforeDays, mutedUntil,
+	)
+	if errors.Is(err, service.ErrInvalidMuteDate) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
 	if errors.Is(err, service.ErrUserNotFound) {
 		errText := fmt.Errorf("user you are trying to subscribe to was not found")
 		WriteErrorResponse(w, http.StatusNotFound, errText)
@@ -137,7 +211,391 @@ func (h *SubscriptionHandler) deleteSubscription(w http.ResponseWriter, r *http.
 	_, _ = w.Write([]byte("subscription deleted"))
 }
 
+func (h *SubscriptionHandler) createSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body createSubscriptionsRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	subscriptions := make([]model.NewSubscription, 0, len(body.Subscriptions))
+	for _, item := range body.Subscriptions {
+		subscription := model.NewSubscription{
+			UserId:           item.UserId,
+			NotifyBeforeDays: item.NotifyBeforeDays,
+		}
+		if item.MutedUntil != nil {
+			mutedUntil, err := time.Parse(time.DateOnly, *item.MutedUntil)
+			if err != nil {
+				errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+				WriteErrorResponse(w, http.StatusBadRequest, errText)
+				return
+			}
+			subscription.MutedUntil = &mutedUntil
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	results, err := h.subscriptionService.CreateSubscriptions(
+		r.Context(), subscriberId, subscriptions, body.Mode == batchModeTransactional,
+	)
+	if errors.Is(err, service.ErrInvalidMuteDate) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(batchSubscriptionResponse{Results: results})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SubscriptionHandler) deleteSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deleteSubscriptionsRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	results, err := h.subscriptionService.DeleteSubscriptions(
+		r.Context(), subscriberId, body.UserIds, body.Mode == batchModeTransactional,
+	)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your subscriptions and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(batchSubscriptionResponse{Results: results})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SubscriptionHandler) getRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	rules, err := h.subscriptionService.FindRules(r.Context(), subscriberId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(rules)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SubscriptionHandler) saveRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body saveSubscriptionRuleRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	rule, err := h.subscriptionService.SaveRule(r.Context(), subscriberId, body.Rule, body.NotifyBeforeDays)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(rule)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SubscriptionHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	rule := chi.URLParam(r, "rule")
+	if err := h.validate.Var(rule, "oneof=everyone department office"); err != nil {
+		errText := fmt.Errorf("rule must be one of: everyone, department, office")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err := h.subscriptionService.DeleteRule(r.Context(), subscriberId, rule)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your subscription rules and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrSubscriptionRuleNotFound) {
+		errText := fmt.Errorf("subscription rule you are trying to delete was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("subscription rule deleted"))
+}
+
+func (h *SubscriptionHandler) updateSubscription(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := chi.URLParam(r, "userId")
+	if err := h.validate.Var(userId, "uuid4"); err != nil {
+		errText := fmt.Errorf("user id must be UUIDv4")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	var body updateSubscriptionRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	update := model.SubscriptionUpdate{
+		NotifyBeforeDays: body.NotifyBeforeDays,
+		SetMutedUntil:    body.MutedUntil.Set,
+	}
+	if body.MutedUntil.Value != nil {
+		mutedUntil, err := time.Parse(time.DateOnly, *body.MutedUntil.Value)
+		if err != nil {
+			errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		update.MutedUntil = &mutedUntil
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	subscription, err := h.subscriptionService.UpdateSubscription(r.Context(), userId, subscriberId, update)
+	if errors.Is(err, service.ErrInvalidMuteDate) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		errText := fmt.Errorf("subscription you are trying to update was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(subscription)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *SubscriptionHandler) muteSubscription(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body muteSubscriptionRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	mutedUntil, err := time.Parse(time.DateOnly, body.MutedUntil)
+	if err != nil {
+		errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.subscriptionService.MuteSubscription(r.Context(), body.UserId, subscriberId, &mutedUntil)
+	if errors.Is(err, service.ErrInvalidMuteDate) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		errText := fmt.Errorf("subscription you are trying to mute was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("subscription muted"))
+}
+
+func (h *SubscriptionHandler) unmuteSubscription(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body unmuteSubscriptionRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	subscriberId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.subscriptionService.MuteSubscription(r.Context(), body.UserId, subscriberId, nil)
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		errText := fmt.Errorf("subscription you are trying to unmute was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("subscription unmuted"))
+}
+
 func (h *SubscriptionHandler) initRoutes() {
 	h.router.With(h.authMiddleware.Auth).Post("/", h.createSubscription)
 	h.router.With(h.authMiddleware.Auth).Delete("/", h.deleteSubscription)
+	h.router.With(h.authMiddleware.Auth).Put("/mute", h.muteSubscription)
+	h.router.With(h.authMiddleware.Auth).Delete("/mute", h.unmuteSubscription)
+	h.router.With(h.authMiddleware.Auth).Post("/batch", h.createSubscriptions)
+	h.router.With(h.authMiddleware.Auth).Delete("/batch", h.deleteSubscriptions)
+	h.router.With(h.authMiddleware.Auth).Get("/rules", h.getRules)
+	h.router.With(h.authMiddleware.Auth).Put("/rules", h.saveRule)
+	h.router.With(h.authMiddleware.Auth).Delete("/rules/{rule}", h.deleteRule)
+	h.router.With(h.authMiddleware.Auth).Patch("/{userId}", h.updateSubscription)
 }
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/telegram"
+)
+
+const telegramSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type TelegramHandler struct {
+	telegramService service.TelegramService
+	authMiddleware  middleware.AuthMiddleware
+	webhookSecret   string
+	router          chi.Router
+}
+
+func NewTelegramHandler(
+	telegramService service.TelegramService,
+	authMiddleware middleware.AuthMiddleware,
+	webhookSecret string,
+) *TelegramHandler {
+	handler := &TelegramHandler{
+		telegramService: telegramService,
+		authMiddleware:  authMiddleware,
+		webhookSecret:   webhookSecret,
+		router:          chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *TelegramHandler) createLinkCode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	linkCode, err := h.telegramService.CreateLinkCode(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(linkCode)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func (h *TelegramHandler) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	// webhook is disabled when no secret is configured
+	if h.webhookSecret == "" {
+		errText := fmt.Errorf("telegram webhook is not configured")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	secret := r.Header.Get(telegramSecretTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(h.webhookSecret)) != 1 {
+		errText := fmt.Errorf("invalid secret token")
+		WriteErrorResponse(w, http.StatusUnauthorized, errText)
+		return
+	}
+
+	var update telegram.Update
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	if err := h.telegramService.HandleUpdate(r.Context(), update); err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("update handled"))
+}
+
+func (h *TelegramHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Post("/link-code", h.createLinkCode)
+	h.router.Post("/webhook", h.handleWebhook)
+}
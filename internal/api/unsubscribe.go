@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+const (
+	unsubscribeScopeSubscription = "subscription"
+	unsubscribeScopeAllEmails    = "all"
+)
+
+var invalidUnsubscribeLinkPage = linkPage{
+	Title:   "Invalid link",
+	Message: "This unsubscribe link is invalid or has expired.",
+}
+
+type UnsubscribeHandler struct {
+	unsubscribeService service.UnsubscribeService
+	router             chi.Router
+}
+
+func NewUnsubscribeHandler(unsubscribeService service.UnsubscribeService) *UnsubscribeHandler {
+	handler := &UnsubscribeHandler{
+		unsubscribeService: unsubscribeService,
+		router:             chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+// getUnsubscribePage does not change anything, since links in emails
+// are often opened by mail scanners without user's intention
+func (h *UnsubscribeHandler) getUnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	err := h.unsubscribeService.CheckToken(r.Context(), token)
+	if err != nil {
+		writeLinkPage(w, http.StatusBadRequest, invalidUnsubscribeLinkPage)
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Unsubscribe",
+		Message: "Stop birthday reminders about this person or all email reminders?",
+		Actions: []linkPageAction{
+			{Name: "scope", Value: unsubscribeScopeSubscription, Label: "Unsubscribe from this person"},
+			{Name: "scope", Value: unsubscribeScopeAllEmails, Label: "Stop all email reminders"},
+		},
+	})
+}
+
+// unsubscribe handles both the form of the unsubscribe page and one-click
+// requests of mail clients, which post List-Unsubscribe=One-Click as described in RFC 8058
+func (h *UnsubscribeHandler) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if err := r.ParseForm(); err != nil {
+		writeLinkPage(w, http.StatusBadRequest, linkPage{
+			Title:   "Bad request",
+			Message: "Failed to read the request.",
+		})
+		return
+	}
+	allEmails := r.PostForm.Get("scope") == unsubscribeScopeAllEmails
+
+	err := h.unsubscribeService.Unsubscribe(r.Context(), token, allEmails)
+	if errors.Is(err, service.ErrInvalidLinkToken) || errors.Is(err, service.ErrUserNotFound) {
+		writeLinkPage(w, http.StatusBadRequest, invalidUnsubscribeLinkPage)
+		return
+	}
+	if err != nil {
+		writeLinkPage(w, http.StatusInternalServerError, linkPage{
+			Title:   "Something went wrong",
+			Message: "Failed to unsubscribe, please try again later.",
+		})
+		return
+	}
+
+	message := "You will no longer receive reminders about this person."
+	if allEmails {
+		message = "You will no longer receive birthday reminders by email."
+	}
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Unsubscribed",
+		Message: message,
+	})
+}
+
+func (h *UnsubscribeHandler) initRoutes() {
+	h.router.Get("/", h.getUnsubscribePage)
+	h.router.Post("/", h.unsubscribe)
+}
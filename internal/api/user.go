@@ -2,27 +2,91 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
 	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+var invalidEmailConfirmationLinkPage = linkPage{
+	Title:   "Invalid link",
+	Message: "This email confirmation link is invalid or has expired.",
+}
+
+const (
+	defaultUsersLimit = 20
+	maxUsersLimit     = 100
+	maxSearchLength   = 100
 )
 
 type UserHandler struct {
 	userService    service.UserService
+	accountService service.AccountService
 	authMiddleware middleware.AuthMiddleware
+	validate       *validator.Validate
 	router         chi.Router
 }
 
+type updateChatHandleRequestBody struct {
+	ChatHandle *string `json:"chat_handle" validate:"omitempty,min=1,max=255"`
+}
+
+type updatePhoneRequestBody struct {
+	Phone *string `json:"phone" validate:"omitempty,e164"`
+}
+
+type updateWorkplaceRequestBody struct {
+	Department *string `json:"department" validate:"omitempty,min=1,max=100"`
+	Office     *string `json:"office" validate:"omitempty,min=1,max=100"`
+}
+
+type updateProfileRequestBody struct {
+	Name         *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Surname      *string `json:"surname" validate:"omitempty,min=1,max=255"`
+	BirthdayDate *string `json:"birthday_date" validate:"omitempty"`
+	Email        *string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+type profileResponseBody struct {
+	model.User
+	// PendingEmail is set when the new email is waiting for confirmation
+	PendingEmail *string `json:"pending_email,omitempty"`
+}
+
+type deleteAccountRequestBody struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type deleteAccountResponseBody struct {
+	// PurgeAt is the time after which the account and all its data are removed
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type usersPageResponseBody struct {
+	Users      []model.DirectoryUser `json:"users"`
+	NextCursor *string               `json:"next_cursor"`
+}
+
 func NewUserHandler(
 	userService service.UserService,
+	accountService service.AccountService,
 	authMiddleware middleware.AuthMiddleware,
 ) *UserHandler {
 	handler := &UserHandler{
 		userService:    userService,
+		accountService: accountService,
 		authMiddleware: authMiddleware,
+		validate:       validatorext.NewValidator(),
 		router:         chi.NewRouter(),
 	}
 	handler.initRoutes()
@@ -31,19 +95,60 @@ func NewUserHandler(
 
 func (h *UserHandler) getUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
+	cursor := r.URL.Query().Get("cursor")
+	if cursor != "" {
+		if err := h.validate.Var(cursor, "uuid4"); err != nil {
+			errText := fmt.Errorf("cursor must be UUIDv4")
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+	}
+
+	limit := defaultUsersLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxUsersLimit {
+			errText := fmt.Errorf("limit must be a number between 1 and %d", maxUsersLimit)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	sort, ok := parseUserSort(r)
+	if !ok {
+		errText := fmt.Errorf("sort must be one of: name, next_birthday")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	search := r.URL.Query().Get("search")
+	if utf8.RuneCountInString(search) > maxSearchLength {
+		errText := fmt.Errorf("search must be at most %d characters long", maxSearchLength)
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
 	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
-	users, err := h.userService.FindAllUsers(r.Context(), userId)
+	users, nextCursor, err := h.userService.FindAllUsers(r.Context(), userId, search, sort, cursor, limit)
+	if errors.Is(err, service.ErrInvalidUserCursor) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
 	if err != nil {
 		WriteErrorResponse(w, http.StatusInternalServerError, err)
 		return
 	}
-	if len(users) == 0 {
-		errText := fmt.Errorf("no users found")
-		WriteErrorResponse(w, http.StatusNotFound, errText)
-		return
+
+	page := usersPageResponseBody{Users: users}
+	if page.Users == nil {
+		page.Users = []model.DirectoryUser{}
+	}
+	if nextCursor != "" {
+		page.NextCursor = &nextCursor
 	}
 
-	response, err := json.Marshal(users)
+	response, err := json.Marshal(page)
 	if err != nil {
 		WriteErrorResponse(w, http.StatusInternalServerError, err)
 		return
@@ -55,18 +160,47 @@ func (h *UserHandler) getUsers(w http.ResponseWriter, r *http.Request) {
 
 func (h *UserHandler) getUsersSubscribedTo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
+	sort, ok := parseUserSort(r)
+	if !ok {
+		errText := fmt.Errorf("sort must be one of: name, next_birthday")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
 	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
-	users, err := h.userService.FindUsersSubscribedTo(r.Context(), userId)
+	subscriptions, err := h.userService.FindUsersSubscribedTo(r.Context(), userId, sort)
 	if err != nil {
 		WriteErrorResponse(w, http.StatusInternalServerError, err)
 		return
 	}
-	if len(users) == 0 {
+	if len(subscriptions) == 0 {
 		errText := fmt.Errorf("no subscriptions found")
 		WriteErrorResponse(w, http.StatusNotFound, errText)
 		return
 	}
 
+	response, err := json.Marshal(subscriptions)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *UserHandler) getSubscribers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	users, err := h.userService.FindSubscribers(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if users == nil {
+		users = []model.User{}
+	}
+
 	response, err := json.Marshal(users)
 	if err != nil {
 		WriteErrorResponse(w, http.StatusInternalServerError, err)
@@ -77,7 +211,430 @@ func (h *UserHandler) getUsersSubscribedTo(w http.ResponseWriter, r *http.Reques
 	_, _ = w.Write(response)
 }
 
+func (h *UserHandler) updateChatHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body updateChatHandleRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.userService.UpdateChatHandle(r.Context(), userId, body.ChatHandle)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("chat handle updated"))
+}
+
+func (h *UserHandler) updatePhone(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body updatePhoneRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.userService.UpdatePhone(r.Context(), userId, body.Phone)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("phone updated"))
+}
+
+func (h *UserHandler) updateWorkplace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body updateWorkplaceRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.userService.UpdateWorkplace(r.Context(), userId, body.Department, body.Office)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("workplace updated"))
+}
+
+func (h *UserHandler) getProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	profile, err := h.userService.FindProfile(r.Context(), userId)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(profileResponseBody{User: profile})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *UserHandler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body updateProfileRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	update := model.UserUpdate{
+		Name:    body.Name,
+		Surname: body.Surname,
+		Email:   body.Email,
+	}
+	if body.BirthdayDate != nil {
+		birthdayDate, err := time.Parse(time.DateOnly, *body.BirthdayDate)
+		if err != nil {
+			errText := fmt.Errorf("wrong date format, please use this format: %s", time.DateOnly)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		if birthdayDate.After(time.Now()) {
+			errText := fmt.Errorf("birthday date must not be in the future")
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		update.BirthdayDate = &birthdayDate
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	profile, confirmationSent, err := h.userService.UpdateProfile(r.Context(), userId, update)
+	if errors.Is(err, service.ErrDuplicateUser) {
+		errText := fmt.Errorf("user with this email already exists")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if errors.Is(err, service.ErrEmailChangeUnavailable) {
+		WriteErrorResponse(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responseBody := profileResponseBody{User: profile}
+	if confirmationSent {
+		responseBody.PendingEmail = body.Email
+	}
+	response, err := json.Marshal(responseBody)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *UserHandler) getUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := chi.URLParam(r, "userId")
+	if err := h.validate.Var(userId, "uuid4"); err != nil {
+		errText := fmt.Errorf("user id must be UUIDv4")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	user, err := h.userService.FindUser(r.Context(), userId)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(user)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+// getEmailConfirmationPage does not change anything, since links in emails
+// are often opened by mail scanners without user's intention
+func (h *UserHandler) getEmailConfirmationPage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if err := h.userService.CheckEmailToken(r.Context(), token); err != nil {
+		writeLinkPage(w, http.StatusBadRequest, invalidEmailConfirmationLinkPage)
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Confirm email",
+		Message: "Use this address for your account and birthday reminders?",
+		Actions: []linkPageAction{
+			{Name: "confirm", Value: "true", Label: "Confirm email"},
+		},
+	})
+}
+
+func (h *UserHandler) confirmEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	err := h.userService.ConfirmEmail(r.Context(), token)
+	if errors.Is(err, service.ErrInvalidLinkToken) {
+		writeLinkPage(w, http.StatusBadRequest, invalidEmailConfirmationLinkPage)
+		return
+	}
+	if errors.Is(err, service.ErrDuplicateUser) {
+		writeLinkPage(w, http.StatusConflict, linkPage{
+			Title:   "Email is taken",
+			Message: "This email is already used by another account.",
+		})
+		return
+	}
+	if err != nil {
+		writeLinkPage(w, http.StatusInternalServerError, linkPage{
+			Title:   "Something went wrong",
+			Message: "Failed to confirm email, please try again later.",
+		})
+		return
+	}
+
+	writeLinkPage(w, http.StatusOK, linkPage{
+		Title:   "Email confirmed",
+		Message: "Your account now uses this email address.",
+	})
+}
+
+func (h *UserHandler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deleteAccountRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	purgeAt, err := h.accountService.ScheduleDeletion(r.Context(), userId, body.Password)
+	if errors.Is(err, service.ErrInvalidPassword) {
+		errText := fmt.Errorf("invalid password")
+		WriteErrorResponse(w, http.StatusUnauthorized, errText)
+		return
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(deleteAccountResponseBody{PurgeAt: purgeAt})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(response)
+}
+
+func (h *UserHandler) restoreAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err := h.accountService.CancelDeletion(r.Context(), userId)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("update result unknown. check your profile and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("account deletion cancelled"))
+}
+
+func (h *UserHandler) exportAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	export, err := h.accountService.Export(r.Context(), userId)
+	if errors.Is(err, service.ErrUserNotFound) {
+		errText := fmt.Errorf("user was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="personal-data.json"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func parseUserSort(r *http.Request) (string, bool) {
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		return model.UserSortName, true
+	}
+	return sort, sort == model.UserSortName || sort == model.UserSortNextBirthday
+}
+
 func (h *UserHandler) initRoutes() {
 	h.router.With(h.authMiddleware.Auth).Get("/", h.getUsers)
 	h.router.With(h.authMiddleware.Auth).Get("/subscriptions", h.getUsersSubscribedTo)
+	h.router.With(h.authMiddleware.Auth).Get("/subscribers", h.getSubscribers)
+	h.router.With(h.authMiddleware.Auth).Put("/chat-handle", h.updateChatHandle)
+	h.router.With(h.authMiddleware.Auth).Put("/phone", h.updatePhone)
+	h.router.With(h.authMiddleware.Auth).Put("/workplace", h.updateWorkplace)
+	h.router.With(h.authMiddleware.Auth).Get("/me", h.getProfile)
+	h.router.With(h.authMiddleware.Auth).Patch("/me", h.updateProfile)
+	h.router.With(h.authMiddleware.Auth).Delete("/me", h.deleteAccount)
+	h.router.With(h.authMiddleware.Auth).Post("/me/restore", h.restoreAccount)
+	h.router.With(h.authMiddleware.Auth).Get("/me/export", h.exportAccount)
+	h.router.With(h.authMiddleware.Auth).Get("/{userId}", h.getUser)
+	h.router.Get("/email/confirm", h.getEmailConfirmationPage)
+	h.router.Post("/email/confirm", h.confirmEmail)
 }
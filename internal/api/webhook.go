@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/vshevchenk0/bday-notifier/internal/middleware"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/validatorext"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	authMiddleware middleware.AuthMiddleware
+	validate       *validator.Validate
+	router         chi.Router
+}
+
+type createWebhookRequestBody struct {
+	Url    string   `json:"url" validate:"required,url,max=1024"`
+	Events []string `json:"events" validate:"required,min=1,unique,dive,oneof=birthday.upcoming birthday.today user.created subscription.created"`
+}
+
+type deleteWebhookRequestBody struct {
+	Id string `json:"id" validate:"required,uuid4"`
+}
+
+func NewWebhookHandler(
+	webhookService service.WebhookService,
+	authMiddleware middleware.AuthMiddleware,
+) *WebhookHandler {
+	handler := &WebhookHandler{
+		webhookService: webhookService,
+		authMiddleware: authMiddleware,
+		validate:       validatorext.NewValidator(),
+		router:         chi.NewRouter(),
+	}
+	handler.initRoutes()
+	return handler
+}
+
+func (h *WebhookHandler) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	webhooks, err := h.webhookService.FindWebhooks(r.Context(), userId)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(webhooks)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body createWebhookRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), userId, body.Url, body.Events)
+	if errors.Is(err, service.ErrInvalidWebhookUrl) {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(webhook)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func (h *WebhookHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	var body deleteWebhookRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		errText := fmt.Errorf("body is required")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+	if err != nil {
+		errText := fmt.Errorf("failed to decode request body")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+
+	err = h.validate.Struct(body)
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		WriteValidationErrorResponse(w, http.StatusBadRequest, validationErrors)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	err = h.webhookService.DeleteWebhook(r.Context(), body.Id, userId)
+	if errors.Is(err, service.ErrOperationResultUnknown) {
+		errText := fmt.Errorf("deletion result unknown. check your webhooks and try again if needed")
+		WriteErrorResponse(w, http.StatusInternalServerError, errText)
+		return
+	}
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		errText := fmt.Errorf("webhook you are trying to delete was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("webhook deleted"))
+}
+
+func (h *WebhookHandler) getDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	webhookId := chi.URLParam(r, "webhookId")
+	if err := h.validate.Var(webhookId, "uuid4"); err != nil {
+		errText := fmt.Errorf("webhook id must be UUIDv4")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" {
+		if err := h.validate.Var(status, "oneof=pending succeeded failed"); err != nil {
+			errText := fmt.Errorf("status must be one of: pending, succeeded, failed")
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+	}
+
+	limit := defaultDeliveriesLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxDeliveriesLimit {
+			errText := fmt.Errorf("limit must be a number between 1 and %d", maxDeliveriesLimit)
+			WriteErrorResponse(w, http.StatusBadRequest, errText)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	deliveries, err := h.webhookService.FindDeliveries(r.Context(), webhookId, userId, status, limit)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+
+	response, err := json.Marshal(deliveries)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (h *WebhookHandler) redeliver(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	deliveryId := chi.URLParam(r, "deliveryId")
+	if err := h.validate.Var(deliveryId, "uuid4"); err != nil {
+		errText := fmt.Errorf("delivery id must be UUIDv4")
+		WriteErrorResponse(w, http.StatusBadRequest, errText)
+		return
+	}
+
+	userId := r.Context().Value(h.authMiddleware.GetUserIdContextKey()).(string)
+	delivery, err := h.webhookService.Redeliver(r.Context(), deliveryId, userId)
+	if errors.Is(err, service.ErrWebhookDeliveryNotFound) {
+		errText := fmt.Errorf("delivery you are trying to redeliver was not found")
+		WriteErrorResponse(w, http.StatusNotFound, errText)
+		return
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(delivery)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(response)
+}
+
+func (h *WebhookHandler) initRoutes() {
+	h.router.With(h.authMiddleware.Auth).Get("/", h.getWebhooks)
+	h.router.With(h.authMiddleware.Auth).Post("/", h.createWebhook)
+	h.router.With(h.authMiddleware.Auth).Delete("/", h.deleteWebhook)
+	h.router.With(h.authMiddleware.Auth).Get("/{webhookId}/deliveries", h.getDeliveries)
+	h.router.With(h.authMiddleware.Auth).Post("/deliveries/{deliveryId}/redeliver", h.redeliver)
+}
@@ -9,36 +9,100 @@ import (
 	"github.com/vshevchenk0/bday-notifier/internal/config"
 	"github.com/vshevchenk0/bday-notifier/internal/middleware"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	birthdayRepository "github.com/vshevchenk0/bday-notifier/internal/repository/birthday"
+	channelRepository "github.com/vshevchenk0/bday-notifier/internal/repository/channel"
+	chatWebhookRepository "github.com/vshevchenk0/bday-notifier/internal/repository/chatwebhook"
+	inboxRepository "github.com/vshevchenk0/bday-notifier/internal/repository/inbox"
+	passwordResetRepository "github.com/vshevchenk0/bday-notifier/internal/repository/passwordreset"
+	pushRepository "github.com/vshevchenk0/bday-notifier/internal/repository/push"
+	refreshTokenRepository "github.com/vshevchenk0/bday-notifier/internal/repository/refreshtoken"
+	reminderRepository "github.com/vshevchenk0/bday-notifier/internal/repository/reminder"
+	settingsRepository "github.com/vshevchenk0/bday-notifier/internal/repository/settings"
 	subscriptionRepository "github.com/vshevchenk0/bday-notifier/internal/repository/subscription"
+	telegramRepository "github.com/vshevchenk0/bday-notifier/internal/repository/telegram"
 	userRepository "github.com/vshevchenk0/bday-notifier/internal/repository/user"
+	webhookRepository "github.com/vshevchenk0/bday-notifier/internal/repository/webhook"
 	"github.com/vshevchenk0/bday-notifier/internal/server"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
+	accountService "github.com/vshevchenk0/bday-notifier/internal/service/account"
 	authService "github.com/vshevchenk0/bday-notifier/internal/service/auth"
+	birthdayService "github.com/vshevchenk0/bday-notifier/internal/service/birthday"
+	channelService "github.com/vshevchenk0/bday-notifier/internal/service/channel"
+	chatWebhookService "github.com/vshevchenk0/bday-notifier/internal/service/chatwebhook"
+	inboxService "github.com/vshevchenk0/bday-notifier/internal/service/inbox"
+	pushService "github.com/vshevchenk0/bday-notifier/internal/service/push"
+	reminderService "github.com/vshevchenk0/bday-notifier/internal/service/reminder"
+	settingsService "github.com/vshevchenk0/bday-notifier/internal/service/settings"
 	subscriptionService "github.com/vshevchenk0/bday-notifier/internal/service/subscription"
+	telegramService "github.com/vshevchenk0/bday-notifier/internal/service/telegram"
+	unsubscribeService "github.com/vshevchenk0/bday-notifier/internal/service/unsubscribe"
 	userService "github.com/vshevchenk0/bday-notifier/internal/service/user"
+	webhookService "github.com/vshevchenk0/bday-notifier/internal/service/webhook"
+	"github.com/vshevchenk0/bday-notifier/pkg/chatwebhook"
 	"github.com/vshevchenk0/bday-notifier/pkg/jwt"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
 	"github.com/vshevchenk0/bday-notifier/pkg/logger"
+	"github.com/vshevchenk0/bday-notifier/pkg/mailer"
+	"github.com/vshevchenk0/bday-notifier/pkg/telegram"
+	"github.com/vshevchenk0/bday-notifier/pkg/webhook"
 )
 
 type serviceProvider struct {
 	config   *config.Config
 	database *sqlx.DB
 
-	tokenManager jwt.Manager
-	logger       *slog.Logger
+	tokenManager      jwt.Manager
+	linkManager       linktoken.Manager
+	logger            *slog.Logger
+	mailer            mailer.Mailer
+	telegramClient    telegram.Client
+	chatWebhookClient chatwebhook.Client
+	webhookSender     webhook.Sender
 
-	userRepository         repository.UserRepository
-	subscriptionRepository repository.SubscriptionRepository
+	userRepository          repository.UserRepository
+	subscriptionRepository  repository.SubscriptionRepository
+	channelRepository       repository.ChannelRepository
+	telegramRepository      repository.TelegramRepository
+	chatWebhookRepository   repository.ChatWebhookRepository
+	webhookRepository       repository.WebhookRepository
+	pushRepository          repository.PushRepository
+	inboxRepository         repository.InboxRepository
+	reminderRepository      repository.ReminderRepository
+	settingsRepository      repository.SettingsRepository
+	birthdayRepository      repository.BirthdayRepository
+	passwordResetRepository repository.PasswordResetRepository
+	refreshTokenRepository  repository.RefreshTokenRepository
 
 	authService         service.AuthService
 	userService         service.UserService
 	subscriptionService service.SubscriptionService
+	channelService      service.ChannelService
+	telegramService     service.TelegramService
+	chatWebhookService  service.ChatWebhookService
+	webhookService      service.WebhookService
+	pushService         service.PushService
+	inboxService        service.InboxService
+	unsubscribeService  service.UnsubscribeService
+	reminderService     service.ReminderService
+	settingsService     service.SettingsService
+	birthdayService     service.BirthdayService
+	accountService      service.AccountService
 
 	authMiddleware middleware.AuthMiddleware
 
 	authHandler         *api.AuthHandler
 	userHandler         *api.UserHandler
 	subscriptionHandler *api.SubscriptionHandler
+	channelHandler      *api.ChannelHandler
+	telegramHandler     *api.TelegramHandler
+	chatWebhookHandler  *api.ChatWebhookHandler
+	webhookHandler      *api.WebhookHandler
+	pushHandler         *api.PushHandler
+	inboxHandler        *api.InboxHandler
+	unsubscribeHandler  *api.UnsubscribeHandler
+	reminderHandler     *api.ReminderHandler
+	settingsHandler     *api.SettingsHandler
+	birthdayHandler     *api.BirthdayHandler
 	router              http.Handler
 
 	serverConfig *server.ServerConfig
@@ -80,6 +144,21 @@ func (s *serviceProvider) TokenManager() jwt.Manager {
 	return s.tokenManager
 }
 
+func (s *serviceProvider) LinkManager() linktoken.Manager {
+	if s.linkManager == nil {
+		managerConfig := &linktoken.ManagerConfig{
+			SigningKey: s.Config().LinkSigningKey,
+			TokenTtl:   s.Config().LinkTokenTtl,
+		}
+		linkManager, err := linktoken.NewManager(managerConfig)
+		if err != nil {
+			panic("failed to init link token manager, LINK_SIGNING_KEY must be set")
+		}
+		s.linkManager = linkManager
+	}
+	return s.linkManager
+}
+
 func (s *serviceProvider) Logger() *slog.Logger {
 	if s.logger == nil {
 		logger := logger.NewLogger(s.Config().Env)
@@ -88,6 +167,56 @@ func (s *serviceProvider) Logger() *slog.Logger {
 	return s.logger
 }
 
+func (s *serviceProvider) Mailer() mailer.Mailer {
+	if s.mailer == nil {
+		mailerConfig := &mailer.MailerConfig{
+			Email:           s.Config().MailerEmail,
+			Password:        s.Config().MailerPassword,
+			SmtpHost:        s.Config().MailerSmtpHost,
+			SmtpPort:        s.Config().MailerSmtpPort,
+			WaitBeforeRetry: s.Config().MailerWaitBeforeRetry,
+			MaxRetriesCount: s.Config().MailerMaxRetriesCount,
+			IncrementalWait: s.Config().MailerIncrementalWait,
+		}
+		s.mailer = mailer.NewMailer(mailerConfig, s.Logger())
+	}
+	return s.mailer
+}
+
+func (s *serviceProvider) TelegramClient() telegram.Client {
+	if s.telegramClient == nil {
+		clientConfig := &telegram.ClientConfig{
+			Token:   s.Config().TelegramBotToken,
+			BaseUrl: s.Config().TelegramApiUrl,
+			Timeout: s.Config().TelegramRequestTimeout,
+		}
+		s.telegramClient = telegram.NewClient(clientConfig)
+	}
+	return s.telegramClient
+}
+
+func (s *serviceProvider) ChatWebhookClient() chatwebhook.Client {
+	if s.chatWebhookClient == nil {
+		clientConfig := &chatwebhook.ClientConfig{
+			Timeout:               s.Config().ChatWebhookRequestTimeout,
+			AllowPrivateAddresses: s.Config().ChatWebhookAllowPrivate,
+		}
+		s.chatWebhookClient = chatwebhook.NewClient(clientConfig)
+	}
+	return s.chatWebhookClient
+}
+
+func (s *serviceProvider) WebhookSender() webhook.Sender {
+	if s.webhookSender == nil {
+		senderConfig := &webhook.SenderConfig{
+			Timeout:               s.Config().WebhookRequestTimeout,
+			AllowPrivateAddresses: s.Config().WebhookAllowPrivate,
+		}
+		s.webhookSender = webhook.NewSender(senderConfig)
+	}
+	return s.webhookSender
+}
+
 func (s *serviceProvider) UserRepository() repository.UserRepository {
 	if s.userRepository == nil {
 		s.userRepository = userRepository.NewRepository(s.Database())
@@ -102,11 +231,100 @@ func (s *serviceProvider) SubscriptionRepository() repository.SubscriptionReposi
 	return s.subscriptionRepository
 }
 
+func (s *serviceProvider) ChannelRepository() repository.ChannelRepository {
+	if s.channelRepository == nil {
+		s.channelRepository = channelRepository.NewRepository(s.Database())
+	}
+	return s.channelRepository
+}
+
+func (s *serviceProvider) TelegramRepository() repository.TelegramRepository {
+	if s.telegramRepository == nil {
+		s.telegramRepository = telegramRepository.NewRepository(s.Database())
+	}
+	return s.telegramRepository
+}
+
+func (s *serviceProvider) ChatWebhookRepository() repository.ChatWebhookRepository {
+	if s.chatWebhookRepository == nil {
+		s.chatWebhookRepository = chatWebhookRepository.NewRepository(s.Database())
+	}
+	return s.chatWebhookRepository
+}
+
+func (s *serviceProvider) WebhookRepository() repository.WebhookRepository {
+	if s.webhookRepository == nil {
+		s.webhookRepository = webhookRepository.NewRepository(s.Database())
+	}
+	return s.webhookRepository
+}
+
+func (s *serviceProvider) PushRepository() repository.PushRepository {
+	if s.pushRepository == nil {
+		s.pushRepository = pushRepository.NewRepository(s.Database())
+	}
+	return s.pushRepository
+}
+
+func (s *serviceProvider) InboxRepository() repository.InboxRepository {
+	if s.inboxRepository == nil {
+		s.inboxRepository = inboxRepository.NewRepository(s.Database())
+	}
+	return s.inboxRepository
+}
+
+func (s *serviceProvider) ReminderRepository() repository.ReminderRepository {
+	if s.reminderRepository == nil {
+		s.reminderRepository = reminderRepository.NewRepository(s.Database())
+	}
+	return s.reminderRepository
+}
+
+func (s *serviceProvider) SettingsRepository() repository.SettingsRepository {
+	if s.settingsRepository == nil {
+		s.settingsRepository = settingsRepository.NewRepository(s.Database())
+	}
+	return s.settingsRepository
+}
+
+func (s *serviceProvider) BirthdayRepository() repository.BirthdayRepository {
+	if s.birthdayRepository == nil {
+		s.birthdayRepository = birthdayRepository.NewRepository(s.Database())
+	}
+	return s.birthdayRepository
+}
+
+func (s *serviceProvider) PasswordResetRepository() repository.PasswordResetRepository {
+	if s.passwordResetRepository == nil {
+		s.passwordResetRepository = passwordResetRepository.NewRepository(s.Database())
+	}
+	return s.passwordResetRepository
+}
+
+func (s *serviceProvider) RefreshTokenRepository() repository.RefreshTokenRepository {
+	if s.refreshTokenRepository == nil {
+		s.refreshTokenRepository = refreshTokenRepository.NewRepository(s.Database())
+	}
+	return s.refreshTokenRepository
+}
+
 func (s *serviceProvider) AuthService() service.AuthService {
 	if s.authService == nil {
+		serviceConfig := &authService.AuthServiceConfig{
+			PublicUrl:                  s.Config().AppPublicUrl,
+			VerificationResendInterval: s.Config().EmailVerificationResendInterval,
+			PasswordResetTokenTtl:      s.Config().PasswordResetTokenTtl,
+			RefreshTokenTtl:            s.Config().RefreshTokenTtl,
+		}
 		s.authService = authService.NewAuthService(
 			s.UserRepository(),
+			s.PasswordResetRepository(),
+			s.RefreshTokenRepository(),
 			s.TokenManager(),
+			s.LinkManager(),
+			s.Mailer(),
+			s.WebhookService(),
+			serviceConfig,
 			s.Logger(),
 		)
 	}
@@ -115,7 +333,16 @@ func (s *serviceProvider) AuthService() service.AuthService {
 
 func (s *serviceProvider) UserService() service.UserService {
 	if s.userService == nil {
-		s.userService = userService.NewUserService(s.UserRepository(), s.Logger())
+		serviceConfig := &userService.UserServiceConfig{
+			PublicUrl: s.Config().AppPublicUrl,
+		}
+		s.userService = userService.NewUserService(
+			s.UserRepository(),
+			s.LinkManager(),
+			s.Mailer(),
+			serviceConfig,
+			s.Logger(),
+		)
 	}
 	return s.userService
 }
@@ -124,12 +351,143 @@ func (s *serviceProvider) SubscriptionService() service.SubscriptionService {
 	if s.subscriptionService == nil {
 		s.subscriptionService = subscriptionService.NewSubscriptionService(
 			s.SubscriptionRepository(),
+			s.UserRepository(),
+			s.SettingsRepository(),
+			s.InboxRepository(),
+			s.WebhookService(),
 			s.Logger(),
 		)
 	}
 	return s.subscriptionService
 }
 
+func (s *serviceProvider) ChannelService() service.ChannelService {
+	if s.channelService == nil {
+		s.channelService = channelService.NewChannelService(
+			s.ChannelRepository(),
+			s.PushRepository(),
+			s.UserRepository(),
+			s.Logger(),
+		)
+	}
+	return s.channelService
+}
+
+func (s *serviceProvider) TelegramService() service.TelegramService {
+	if s.telegramService == nil {
+		s.telegramService = telegramService.NewTelegramService(
+			s.TelegramRepository(),
+			s.ChannelRepository(),
+			s.TelegramClient(),
+			s.Config().TelegramLinkCodeTtl,
+			s.Logger(),
+		)
+	}
+	return s.telegramService
+}
+
+func (s *serviceProvider) ChatWebhookService() service.ChatWebhookService {
+	if s.chatWebhookService == nil {
+		s.chatWebhookService = chatWebhookService.NewChatWebhookService(
+			s.ChatWebhookRepository(),
+			s.ChatWebhookClient(),
+			s.Logger(),
+		)
+	}
+	return s.chatWebhookService
+}
+
+func (s *serviceProvider) WebhookService() service.WebhookService {
+	if s.webhookService == nil {
+		serviceConfig := &webhookService.WebhookServiceConfig{
+			MaxAttempts:  s.Config().WebhookMaxAttempts,
+			RetryBackoff: s.Config().WebhookRetryBackoff,
+			UpcomingDays: s.Config().WebhookUpcomingDays,
+		}
+		s.webhookService = webhookService.NewWebhookService(
+			s.WebhookRepository(),
+			s.WebhookSender(),
+			serviceConfig,
+			s.Logger(),
+		)
+	}
+	return s.webhookService
+}
+
+func (s *serviceProvider) PushService() service.PushService {
+	if s.pushService == nil {
+		s.pushService = pushService.NewPushService(s.PushRepository(), s.Logger())
+	}
+	return s.pushService
+}
+
+func (s *serviceProvider) InboxService() service.InboxService {
+	if s.inboxService == nil {
+		s.inboxService = inboxService.NewInboxService(
+			s.InboxRepository(),
+			s.Config().InboxRetention,
+			s.Logger(),
+		)
+	}
+	return s.inboxService
+}
+
+func (s *serviceProvider) UnsubscribeService() service.UnsubscribeService {
+	if s.unsubscribeService == nil {
+		s.unsubscribeService = unsubscribeService.NewUnsubscribeService(
+			s.SubscriptionRepository(),
+			s.ChannelRepository(),
+			s.LinkManager(),
+			s.Logger(),
+		)
+	}
+	return s.unsubscribeService
+}
+
+func (s *serviceProvider) ReminderService() service.ReminderService {
+	if s.reminderService == nil {
+		s.reminderService = reminderService.NewReminderService(
+			s.ReminderRepository(),
+			s.LinkManager(),
+			s.Logger(),
+		)
+	}
+	return s.reminderService
+}
+
+func (s *serviceProvider) SettingsService() service.SettingsService {
+	if s.settingsService == nil {
+		s.settingsService = settingsService.NewSettingsService(s.SettingsRepository(), s.Logger())
+	}
+	return s.settingsService
+}
+
+func (s *serviceProvider) BirthdayService() service.BirthdayService {
+	if s.birthdayService == nil {
+		s.birthdayService = birthdayService.NewBirthdayService(s.BirthdayRepository(), s.Logger())
+	}
+	return s.birthdayService
+}
+
+func (s *serviceProvider) AccountService() service.AccountService {
+	if s.accountService == nil {
+		s.accountService = accountService.NewAccountService(
+			s.UserRepository(),
+			s.SubscriptionRepository(),
+			s.ChannelRepository(),
+			s.SettingsRepository(),
+			s.InboxRepository(),
+			s.ReminderRepository(),
+			s.WebhookRepository(),
+			s.ChatWebhookRepository(),
+			s.PushRepository(),
+			s.Config().AccountDeletionGracePeriod,
+			s.Logger(),
+		)
+	}
+	return s.accountService
+}
+
 func (s *serviceProvider) AuthMiddleware() middleware.AuthMiddleware {
 	if s.authMiddleware == nil {
 		s.authMiddleware = middleware.NewAuthMiddleware(s.AuthService())
@@ -139,14 +497,14 @@ func (s *serviceProvider) AuthMiddleware() middleware.AuthMiddleware {
 
 func (s *serviceProvider) AuthHandler() *api.AuthHandler {
 	if s.authHandler == nil {
-		s.authHandler = api.NewAuthHandler(s.AuthService())
+		s.authHandler = api.NewAuthHandler(s.AuthService(), s.AuthMiddleware())
 	}
 	return s.authHandler
 }
 
 func (s *serviceProvider) UserHandler() *api.UserHandler {
 	if s.userHandler == nil {
-		s.userHandler = api.NewUserHandler(s.UserService(), s.AuthMiddleware())
+		s.userHandler = api.NewUserHandler(s.UserService(), s.AccountService(), s.AuthMiddleware())
 	}
 	return s.userHandler
 }
@@ -161,9 +519,101 @@ func (s *serviceProvider) SubscriptionHandler() *api.SubscriptionHandler {
 	return s.subscriptionHandler
 }
 
+func (s *serviceProvider) ChannelHandler() *api.ChannelHandler {
+	if s.channelHandler == nil {
+		s.channelHandler = api.NewChannelHandler(s.ChannelService(), s.AuthMiddleware())
+	}
+	return s.channelHandler
+}
+
+func (s *serviceProvider) TelegramHandler() *api.TelegramHandler {
+	if s.telegramHandler == nil {
+		s.telegramHandler = api.NewTelegramHandler(
+			s.TelegramService(),
+			s.AuthMiddleware(),
+			s.Config().TelegramWebhookSecret,
+		)
+	}
+	return s.telegramHandler
+}
+
+func (s *serviceProvider) ChatWebhookHandler() *api.ChatWebhookHandler {
+	if s.chatWebhookHandler == nil {
+		s.chatWebhookHandler = api.NewChatWebhookHandler(s.ChatWebhookService(), s.AuthMiddleware())
+	}
+	return s.chatWebhookHandler
+}
+
+func (s *serviceProvider) WebhookHandler() *api.WebhookHandler {
+	if s.webhookHandler == nil {
+		s.webhookHandler = api.NewWebhookHandler(s.WebhookService(), s.AuthMiddleware())
+	}
+	return s.webhookHandler
+}
+
+func (s *serviceProvider) PushHandler() *api.PushHandler {
+	if s.pushHandler == nil {
+		s.pushHandler = api.NewPushHandler(
+			s.PushService(),
+			s.AuthMiddleware(),
+			s.Config().VapidPublicKey,
+		)
+	}
+	return s.pushHandler
+}
+
+func (s *serviceProvider) InboxHandler() *api.InboxHandler {
+	if s.inboxHandler == nil {
+		s.inboxHandler = api.NewInboxHandler(s.InboxService(), s.AuthMiddleware())
+	}
+	return s.inboxHandler
+}
+
+func (s *serviceProvider) UnsubscribeHandler() *api.UnsubscribeHandler {
+	if s.unsubscribeHandler == nil {
+		s.unsubscribeHandler = api.NewUnsubscribeHandler(s.UnsubscribeService())
+	}
+	return s.unsubscribeHandler
+}
+
+func (s *serviceProvider) ReminderHandler() *api.ReminderHandler {
+	if s.reminderHandler == nil {
+		s.reminderHandler = api.NewReminderHandler(s.ReminderService())
+	}
+	return s.reminderHandler
+}
+
+func (s *serviceProvider) SettingsHandler() *api.SettingsHandler {
+	if s.settingsHandler == nil {
+		s.settingsHandler = api.NewSettingsHandler(s.SettingsService(), s.AuthMiddleware())
+	}
+	return s.settingsHandler
+}
+
+func (s *serviceProvider) BirthdayHandler() *api.BirthdayHandler {
+	if s.birthdayHandler == nil {
+		s.birthdayHandler = api.NewBirthdayHandler(s.BirthdayService(), s.AuthMiddleware())
+	}
+	return s.birthdayHandler
+}
+
 func (s *serviceProvider) Router() http.Handler {
 	if s.router == nil {
-		s.router = api.NewRouter(s.AuthHandler(), s.SubscriptionHandler(), s.UserHandler())
+		s.router = api.NewRouter(
+			s.AuthHandler(),
+			s.SubscriptionHandler(),
+			s.UserHandler(),
+			s.ChannelHandler(),
+			s.TelegramHandler(),
+			s.ChatWebhookHandler(),
+			s.WebhookHandler(),
+			s.PushHandler(),
+			s.InboxHandler(),
+			s.UnsubscribeHandler(),
+			s.ReminderHandler(),
+			s.SettingsHandler(),
+			s.BirthdayHandler(),
+		)
 	}
 	return s.router
 }
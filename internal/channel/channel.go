@@ -0,0 +1,22 @@
+package channel
+
+import (
+	"context"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+)
+
+type Message struct {
+	Notification model.Notification
+	Subject      string
+	Body         string
+	// signed links for the subscriber, empty if links are not configured
+	UnsubscribeUrl   string
+	SnoozeUrl        string
+	CongratulatedUrl string
+}
+
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, message Message) error
+}
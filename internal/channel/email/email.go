@@ -0,0 +1,51 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/pkg/mailer"
+)
+
+type emailChannel struct {
+	mailer mailer.Mailer
+}
+
+func NewChannel(mailer mailer.Mailer) *emailChannel {
+	return &emailChannel{
+		mailer: mailer,
+	}
+}
+
+func (c *emailChannel) Name() string {
+	return model.ChannelEmail
+}
+
+func (c *emailChannel) Send(ctx context.Context, message channel.Message) error {
+	addresses := []string{message.Notification.Address}
+	body := &strings.Builder{}
+	body.WriteString(message.Body)
+	if message.SnoozeUrl != "" || message.CongratulatedUrl != "" {
+		body.WriteString("\n")
+	}
+	if message.SnoozeUrl != "" {
+		fmt.Fprintf(body, "\nRemind me again tomorrow: %s", message.SnoozeUrl)
+	}
+	if message.CongratulatedUrl != "" {
+		fmt.Fprintf(body, "\nI've already congratulated: %s", message.CongratulatedUrl)
+	}
+	if message.UnsubscribeUrl == "" {
+		return c.mailer.Send(ctx, addresses, message.Subject, body.String())
+	}
+
+	// one-click unsubscribe headers as described in RFC 8058
+	headers := map[string]string{
+		"List-Unsubscribe":      fmt.Sprintf("<%s>", message.UnsubscribeUrl),
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	fmt.Fprintf(body, "\n\nUnsubscribe from these reminders: %s", message.UnsubscribeUrl)
+	return c.mailer.SendWithHeaders(ctx, addresses, message.Subject, body.String(), headers)
+}
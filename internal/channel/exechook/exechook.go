@@ -0,0 +1,137 @@
+package exechook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/pkg/exechook"
+)
+
+type ExecHookChannelConfig struct {
+	MaxAttempts    int
+	RetryBackoff   time.Duration
+	RetryExitCodes []int
+}
+
+type payload struct {
+	SubscriberId      string          `json:"subscriber_id"`
+	Address           string          `json:"address"`
+	Subject           string          `json:"subject"`
+	Body              string          `json:"body"`
+	BirthdayUser      payloadBirthday `json:"birthday_user"`
+	DaysUntilBirthday int             `json:"days_until_birthday"`
+}
+
+type payloadBirthday struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Surname      string `json:"surname"`
+	BirthdayDate string `json:"birthday_date"`
+}
+
+type execHookChannel struct {
+	runner         exechook.Runner
+	maxAttempts    int
+	retryBackoff   time.Duration
+	retryExitCodes map[int]struct{}
+	logger         *slog.Logger
+}
+
+func NewChannel(runner exechook.Runner, config *ExecHookChannelConfig, logger *slog.Logger) *execHookChannel {
+	maxAttempts := config.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	retryExitCodes := make(map[int]struct{}, len(config.RetryExitCodes))
+	for _, code := range config.RetryExitCodes {
+		retryExitCodes[code] = struct{}{}
+	}
+	return &execHookChannel{
+		runner:         runner,
+		maxAttempts:    maxAttempts,
+		retryBackoff:   config.RetryBackoff,
+		retryExitCodes: retryExitCodes,
+		logger:         logger,
+	}
+}
+
+func (c *execHookChannel) Name() string {
+	return model.ChannelExec
+}
+
+func (c *execHookChannel) Send(ctx context.Context, message channel.Message) error {
+	notification := message.Notification
+	birthdayDate := notification.BirthdayDate.Format(time.DateOnly)
+	stdin, err := json.Marshal(payload{
+		SubscriberId: notification.SubscriberId,
+		Address:      notification.Address,
+		Subject:      message.Subject,
+		Body:         message.Body,
+		BirthdayUser: payloadBirthday{
+			Id:           notification.BirthdayUserId,
+			Name:         notification.BirthdayUserName,
+			Surname:      notification.BirthdayUserSurname,
+			BirthdayDate: birthdayDate,
+		},
+		DaysUntilBirthday: notification.DaysUntilBirthday,
+	})
+	if err != nil {
+		return err
+	}
+	env := []string{
+		"BDAY_SUBSCRIBER_ID=" + notification.SubscriberId,
+		"BDAY_ADDRESS=" + notification.Address,
+		"BDAY_SUBJECT=" + message.Subject,
+		"BDAY_BIRTHDAY_USER_ID=" + notification.BirthdayUserId,
+		"BDAY_BIRTHDAY_USER_NAME=" + notification.BirthdayUserName,
+		"BDAY_BIRTHDAY_USER_SURNAME=" + notification.BirthdayUserSurname,
+		"BDAY_BIRTHDAY_DATE=" + birthdayDate,
+		"BDAY_DAYS_UNTIL_BIRTHDAY=" + strconv.Itoa(notification.DaysUntilBirthday),
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, err := c.run(ctx, stdin, env, attempt)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= c.maxAttempts {
+			return err
+		}
+		// backoff grows exponentially, same as for outgoing webhooks
+		select {
+		case <-time.After(c.retryBackoff * time.Duration(1<<(attempt-1))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run executes the hook once and reports whether the failure is worth retrying
+func (c *execHookChannel) run(ctx context.Context, stdin []byte, env []string, attempt int) (bool, error) {
+	result, err := c.runner.Run(ctx, stdin, env)
+	c.logger.Info(
+		"exec hook finished",
+		slog.Int("attempt", attempt),
+		slog.Int("exit_code", result.ExitCode),
+		slog.String("stdout", result.Stdout),
+		slog.String("stderr", result.Stderr),
+	)
+	if errors.Is(err, exechook.ErrTimeout) {
+		return true, err
+	}
+	if err != nil {
+		return false, err
+	}
+	if result.ExitCode == 0 {
+		return false, nil
+	}
+	_, retry := c.retryExitCodes[result.ExitCode]
+	return retry, fmt.Errorf("exec hook exited with code %d", result.ExitCode)
+}
@@ -0,0 +1,66 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/pkg/sms"
+)
+
+type SmsChannelConfig struct {
+	MaxParts       int
+	RecipientLimit int
+	TotalLimit     int
+}
+
+type smsChannel struct {
+	gateway        sms.Gateway
+	smsRepository  repository.SmsRepository
+	maxParts       int
+	recipientLimit int
+	totalLimit     int
+}
+
+func NewChannel(
+	gateway sms.Gateway,
+	smsRepository repository.SmsRepository,
+	config *SmsChannelConfig,
+) *smsChannel {
+	maxParts := config.MaxParts
+	if maxParts < 1 {
+		maxParts = 1
+	}
+	return &smsChannel{
+		gateway:        gateway,
+		smsRepository:  smsRepository,
+		maxParts:       maxParts,
+		recipientLimit: config.RecipientLimit,
+		totalLimit:     config.TotalLimit,
+	}
+}
+
+func (c *smsChannel) Name() string {
+	return model.ChannelSms
+}
+
+func (c *smsChannel) Send(ctx context.Context, message channel.Message) error {
+	// subject is omitted, since body already names the birthday person
+	parts := sms.Split(message.Body, c.maxParts)
+	phone := message.Notification.Address
+
+	// every part is a separate message, which is paid for
+	err := c.smsRepository.ReserveMessages(ctx, phone, len(parts), c.recipientLimit, c.totalLimit)
+	if err != nil {
+		return fmt.Errorf("failed to reserve sms: %w", err)
+	}
+
+	for _, part := range parts {
+		if err := c.gateway.Send(ctx, phone, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
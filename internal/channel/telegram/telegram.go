@@ -0,0 +1,29 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/pkg/telegram"
+)
+
+type telegramChannel struct {
+	client telegram.Client
+}
+
+func NewChannel(client telegram.Client) *telegramChannel {
+	return &telegramChannel{
+		client: client,
+	}
+}
+
+func (c *telegramChannel) Name() string {
+	return model.ChannelTelegram
+}
+
+func (c *telegramChannel) Send(ctx context.Context, message channel.Message) error {
+	text := fmt.Sprintf("%s\n\n%s", message.Subject, message.Body)
+	return c.client.SendMessage(ctx, message.Notification.Address, text)
+}
@@ -0,0 +1,73 @@
+package webpush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/pkg/webpush"
+)
+
+type payload struct {
+	Title string      `json:"title"`
+	Body  string      `json:"body"`
+	Data  payloadData `json:"data"`
+}
+
+type payloadData struct {
+	BirthdayUserId string `json:"birthday_user_id"`
+}
+
+type webPushChannel struct {
+	client         webpush.Client
+	pushRepository repository.PushRepository
+}
+
+func NewChannel(client webpush.Client, pushRepository repository.PushRepository) *webPushChannel {
+	return &webPushChannel{
+		client:         client,
+		pushRepository: pushRepository,
+	}
+}
+
+func (c *webPushChannel) Name() string {
+	return model.ChannelWebPush
+}
+
+func (c *webPushChannel) Send(ctx context.Context, message channel.Message) error {
+	// address of the webpush channel is the id of the browser subscription, which must belong to the recipient
+	subscription, err := c.pushRepository.FindSubscription(
+		ctx, message.Notification.Address, message.Notification.SubscriberId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find push subscription: %w", err)
+	}
+
+	body, err := json.Marshal(payload{
+		Title: message.Subject,
+		Body:  message.Body,
+		Data: payloadData{
+			BirthdayUserId: message.Notification.BirthdayUserId,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.client.Send(ctx, webpush.Subscription{
+		Endpoint: subscription.Endpoint,
+		P256dh:   subscription.P256dh,
+		Auth:     subscription.Auth,
+	}, body)
+	if errors.Is(err, webpush.ErrSubscriptionGone) {
+		// browser has unsubscribed, the subscription will never work again
+		if deleteErr := c.pushRepository.DeleteSubscription(ctx, subscription.Id); deleteErr != nil {
+			return fmt.Errorf("%w, failed to prune it: %w", err, deleteErr)
+		}
+	}
+	return err
+}
@@ -9,12 +9,20 @@ import (
 type Config struct {
 	Env string `env:"ENV"`
 
-	AppHost string `env:"APP_HOST"`
-	AppPort string `env:"APP_PORT"`
+	AppHost      string `env:"APP_HOST"`
+	AppPort      string `env:"APP_PORT"`
+	AppPublicUrl string `env:"APP_PUBLIC_URL"`
 
 	JwtSigningKey string        `env:"JWT_SIGNING_KEY,unset"`
 	JwtTokenTtl   time.Duration `env:"JWT_TOKEN_TTL" envDefault:"1h"`
 
+	LinkSigningKey string        `env:"LINK_SIGNING_KEY,unset"`
+	LinkTokenTtl   time.Duration `env:"LINK_TOKEN_TTL" envDefault:"720h"`
+
+	EmailVerificationResendInterval time.Duration `env:"EMAIL_VERIFICATION_RESEND_INTERVAL" envDefault:"5m"`
+	PasswordResetTokenTtl           time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"1h"`
+	RefreshTokenTtl                 time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+
 	MailerEmail           string        `env:"MAILER_EMAIL"`
 	MailerPassword        string        `env:"MAILER_PASSWORD"`
 	MailerSmtpHost        string        `env:"MAILER_SMTP_HOST"`
@@ -23,6 +31,51 @@ type Config struct {
 	MailerMaxRetriesCount int           `env:"MAILER_MAX_RETRIES_COUNT"`
 	MailerIncrementalWait bool          `env:"MAILER_INCREMENTAL_WAIT"`
 
+	TelegramBotToken       string        `env:"TELEGRAM_BOT_TOKEN,unset"`
+	TelegramApiUrl         string        `env:"TELEGRAM_API_URL" envDefault:"https://api.telegram.org"`
+	TelegramRequestTimeout time.Duration `env:"TELEGRAM_REQUEST_TIMEOUT" envDefault:"10s"`
+	TelegramWebhookSecret  string        `env:"TELEGRAM_WEBHOOK_SECRET,unset"`
+	TelegramLinkCodeTtl    time.Duration `env:"TELEGRAM_LINK_CODE_TTL" envDefault:"15m"`
+
+	ChatWebhookRequestTimeout time.Duration `env:"CHAT_WEBHOOK_REQUEST_TIMEOUT" envDefault:"10s"`
+	ChatWebhookAllowPrivate   bool          `env:"CHAT_WEBHOOK_ALLOW_PRIVATE_ADDRESSES"`
+
+	WebhookRequestTimeout time.Duration `env:"WEBHOOK_REQUEST_TIMEOUT" envDefault:"10s"`
+	WebhookMaxAttempts    int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"5"`
+	WebhookRetryBackoff   time.Duration `env:"WEBHOOK_RETRY_BACKOFF" envDefault:"10s"`
+	WebhookUpcomingDays   int           `env:"WEBHOOK_UPCOMING_DAYS" envDefault:"7"`
+	WebhookAllowPrivate   bool          `env:"WEBHOOK_ALLOW_PRIVATE_ADDRESSES"`
+
+	SmsGatewayUrl             string        `env:"SMS_GATEWAY_URL,unset"`
+	SmsGatewayAuthHeaderName  string        `env:"SMS_GATEWAY_AUTH_HEADER_NAME" envDefault:"Authorization"`
+	SmsGatewayAuthHeaderValue string        `env:"SMS_GATEWAY_AUTH_HEADER_VALUE,unset"`
+	SmsGatewayPayloadFormat   string        `env:"SMS_GATEWAY_PAYLOAD_FORMAT" envDefault:"json"`
+	SmsGatewayPayloadTemplate string        `env:"SMS_GATEWAY_PAYLOAD_TEMPLATE"`
+	SmsGatewayRequestTimeout  time.Duration `env:"SMS_GATEWAY_REQUEST_TIMEOUT" envDefault:"10s"`
+	SmsMaxParts               int           `env:"SMS_MAX_PARTS" envDefault:"1"`
+	SmsDailyLimitPerRecipient int           `env:"SMS_DAILY_LIMIT_PER_RECIPIENT" envDefault:"5"`
+	SmsDailyLimit             int           `env:"SMS_DAILY_LIMIT" envDefault:"100"`
+
+	VapidPublicKey        string        `env:"VAPID_PUBLIC_KEY"`
+	VapidPrivateKey       string        `env:"VAPID_PRIVATE_KEY,unset"`
+	VapidSubject          string        `env:"VAPID_SUBJECT"`
+	WebPushTtl            time.Duration `env:"WEBPUSH_TTL" envDefault:"24h"`
+	WebPushRequestTimeout time.Duration `env:"WEBPUSH_REQUEST_TIMEOUT" envDefault:"10s"`
+
+	ExecHookPath           string        `env:"EXEC_HOOK_PATH"`
+	ExecHookArgs           []string      `env:"EXEC_HOOK_ARGS" envSeparator:" "`
+	ExecHookTimeout        time.Duration `env:"EXEC_HOOK_TIMEOUT" envDefault:"30s"`
+	ExecHookConcurrency    int           `env:"EXEC_HOOK_CONCURRENCY" envDefault:"4"`
+	ExecHookMaxAttempts    int           `env:"EXEC_HOOK_MAX_ATTEMPTS" envDefault:"3"`
+	ExecHookRetryBackoff   time.Duration `env:"EXEC_HOOK_RETRY_BACKOFF" envDefault:"5s"`
+	ExecHookRetryExitCodes []int         `env:"EXEC_HOOK_RETRY_EXIT_CODES" envDefault:"75" envSeparator:","`
+
+	NotificationConcurrency int `env:"NOTIFICATION_CONCURRENCY" envDefault:"10"`
+
+	InboxRetention time.Duration `env:"INBOX_RETENTION" envDefault:"2160h"`
+
+	AccountDeletionGracePeriod time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" envDefault:"720h"`
+
 	DatabaseUser     string `env:"DB_USER"`
 	DatabasePassword string `env:"DB_PASSWORD"`
 	DatabaseHost     string `env:"DB_HOST"`
@@ -8,17 +8,22 @@ import (
 	"github.com/vshevchenk0/bday-notifier/internal/service"
 )
 
-const UserIdKey key = "userId"
+const (
+	UserIdKey  key = "userId"
+	TokenIdKey key = "tokenId"
+)
 
 type authMiddleware struct {
-	authService      service.AuthService
-	userIdContextKey key
+	authService       service.AuthService
+	userIdContextKey  key
+	tokenIdContextKey key
 }
 
 func NewAuthMiddleware(authService service.AuthService) *authMiddleware {
 	return &authMiddleware{
-		authService:      authService,
-		userIdContextKey: UserIdKey,
+		authService:       authService,
+		userIdContextKey:  UserIdKey,
+		tokenIdContextKey: TokenIdKey,
 	}
 }
 
@@ -36,13 +41,14 @@ func (m *authMiddleware) Auth(h http.Handler) http.Handler {
 			_, _ = w.Write([]byte("malformed token"))
 			return
 		}
-		userId, err := m.authService.VerifyToken(r.Context(), headerParts[1])
+		userId, tokenId, err := m.authService.VerifyToken(r.Context(), headerParts[1])
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			_, _ = w.Write([]byte("token expired"))
 			return
 		}
 		ctx := context.WithValue(r.Context(), m.userIdContextKey, userId)
+		ctx = context.WithValue(ctx, m.tokenIdContextKey, tokenId)
 		h.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -50,3 +56,7 @@ func (m *authMiddleware) Auth(h http.Handler) http.Handler {
 func (m *authMiddleware) GetUserIdContextKey() key {
 	return m.userIdContextKey
 }
+
+func (m *authMiddleware) GetTokenIdContextKey() key {
+	return m.tokenIdContextKey
+}
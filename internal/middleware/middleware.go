@@ -7,4 +7,5 @@ type key string
 type AuthMiddleware interface {
 	Auth(h http.Handler) http.Handler
 	GetUserIdContextKey() key
+	GetTokenIdContextKey() key
 }
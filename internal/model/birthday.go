@@ -0,0 +1,22 @@
+package model
+
+const (
+	BirthdayScopeSubscribed = "subscribed"
+	BirthdayScopeAll        = "all"
+)
+
+// UserBirthday is a particular birthday of the user. february 29 birthdays fall on february 28 in non-leap years
+type UserBirthday struct {
+	User              User   `json:"user" db:"user"`
+	Date              string `json:"date" db:"date"`
+	DaysUntilBirthday int    `json:"days_until_birthday" db:"days_until_birthday"`
+	// age the user turns on this birthday, omitted if the user hides it
+	Age *int `json:"age,omitempty" db:"age"`
+	// set when the current user gets reminders about the user, by a subscription or a subscription rule
+	Subscribed bool `json:"subscribed" db:"subscribed"`
+}
+
+type CalendarDay struct {
+	Date      string         `json:"date"`
+	Birthdays []UserBirthday `json:"birthdays"`
+}
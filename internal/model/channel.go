@@ -0,0 +1,29 @@
+package model
+
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelSms      = "sms"
+	ChannelWebPush  = "webpush"
+	ChannelExec     = "exec"
+)
+
+const (
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+type UserChannel struct {
+	UserId  string `json:"user_id,omitempty" db:"user_id"`
+	Channel string `json:"channel" db:"channel"`
+	Address string `json:"address" db:"address"`
+	Enabled bool   `json:"enabled" db:"enabled"`
+}
+
+type SubscriptionChannel struct {
+	UserId       string  `json:"user_id,omitempty" db:"user_id"`
+	SubscriberId string  `json:"subscriber_id,omitempty" db:"subscriber_id"`
+	Channel      string  `json:"channel" db:"channel"`
+	Address      *string `json:"address,omitempty" db:"address"`
+	Enabled      bool    `json:"enabled" db:"enabled"`
+}
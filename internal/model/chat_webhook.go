@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const (
+	ChatWebhookFormatSlack      = "slack"
+	ChatWebhookFormatMattermost = "mattermost"
+)
+
+type ChatWebhook struct {
+	Id               string `json:"id" db:"id"`
+	OwnerId          string `json:"-" db:"owner_id"`
+	Name             string `json:"name" db:"name"`
+	Url              string `json:"url" db:"url"`
+	Format           string `json:"format" db:"format"`
+	AnnounceToday    bool   `json:"announce_today" db:"announce_today"`
+	RemindBeforeDays int    `json:"remind_before_days" db:"remind_before_days"`
+	Enabled          bool   `json:"enabled" db:"enabled"`
+}
+
+type Announcement struct {
+	WebhookId         string    `db:"webhook_id"`
+	WebhookUrl        string    `db:"webhook_url"`
+	WebhookFormat     string    `db:"webhook_format"`
+	UserId            string    `db:"user_id"`
+	UserName          string    `db:"user_name"`
+	UserSurname       string    `db:"user_surname"`
+	UserChatHandle    *string   `db:"user_chat_handle"`
+	BirthdayDate      time.Time `db:"birthday_date"`
+	DaysUntilBirthday int       `db:"days_until_birthday"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type InboxNotification struct {
+	Id             string     `json:"id" db:"id"`
+	UserId         string     `json:"-" db:"user_id"`
+	BirthdayUserId string     `json:"birthday_user_id" db:"birthday_user_id"`
+	Subject        string     `json:"subject" db:"subject"`
+	Body           string     `json:"body" db:"body"`
+	ReadAt         *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// actions of signed links sent to users, token of every link is valid only for its action
+const (
+	LinkActionUnsubscribe   = "unsubscribe"
+	LinkActionSnooze        = "snooze"
+	LinkActionCongratulated = "congratulated"
+	LinkActionConfirmEmail  = "confirm_email"
+	LinkActionVerifyEmail   = "verify_email"
+)
@@ -7,6 +7,32 @@ type Notification struct {
 	BirthdayUserName    string    `db:"birthday_user_name"`
 	BirthdayUserSurname string    `db:"birthday_user_surname"`
 	BirthdayDate        time.Time `db:"birthday_date"`
-	SubscriberEmail     string    `db:"subscriber_email"`
+	BirthdayOccurrence  time.Time `db:"birthday_occurrence"`
+	SubscriberId        string    `db:"subscriber_id"`
+	Channel             string    `db:"channel"`
+	Address             string    `db:"address"`
 	DaysUntilBirthday   int       `db:"days_until_birthday"`
+	// set when reminder is forwarded from the subscriber on vacation
+	ForwardedFromId      *string `db:"forwarded_from_id"`
+	ForwardedFromName    *string `db:"forwarded_from_name"`
+	ForwardedFromSurname *string `db:"forwarded_from_surname"`
+	// set when reminder comes from a subscription rule instead of an explicit subscription
+	SubscriptionRule *string `db:"subscription_rule"`
+}
+
+// ReminderState is the snooze or congratulation of the subscriber for one occurrence of the birthday
+type ReminderState struct {
+	UserId          string     `json:"user_id" db:"user_id"`
+	Occurrence      string     `json:"occurrence" db:"occurrence"`
+	SnoozedUntil    *string    `json:"snoozed_until" db:"snoozed_until"`
+	CongratulatedAt *time.Time `json:"congratulated_at" db:"congratulated_at"`
+}
+
+type Delivery struct {
+	BirthdayUserId string `db:"birthday_user_id"`
+	SubscriberId   string `db:"subscriber_id"`
+	Channel        string `db:"channel"`
+	Address        string `db:"address"`
+	Status         string `db:"status"`
+	Error          string `db:"error"`
 }
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type PushSubscription struct {
+	Id        string    `json:"id" db:"id"`
+	UserId    string    `json:"-" db:"user_id"`
+	Endpoint  string    `json:"endpoint" db:"endpoint"`
+	P256dh    string    `json:"-" db:"p256dh"`
+	Auth      string    `json:"-" db:"auth"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
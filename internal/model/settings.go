@@ -0,0 +1,16 @@
+package model
+
+type Vacation struct {
+	From       string  `json:"from" db:"vacation_from"`
+	Until      string  `json:"until" db:"vacation_until"`
+	DelegateId *string `json:"delegate_id" db:"vacation_delegate_id"`
+}
+
+type Privacy struct {
+	// subscriptions of the user are hidden from the users subscribed to
+	HideSubscriptions bool `json:"hide_subscriptions" db:"hide_subscriptions"`
+	// user is notified when someone subscribes to them
+	NotifyNewSubscribers bool `json:"notify_new_subscribers" db:"notify_new_subscribers"`
+	// age of the user is not shown to other users
+	HideAge bool `json:"hide_age" db:"hide_age"`
+}
@@ -1,7 +1,60 @@
 package model
 
+import "time"
+
 type Subscription struct {
-	UserId           string `json:"user_id,omitempty" db:"user_id"`
-	SubscriberId     string `json:"subscriber_id,omitempty" db:"subscriber_id"`
-	NotifyBeforeDays int    `json:"notify_before_days,omitempty" db:"notify_before_days"`
+	UserId           string  `json:"user_id,omitempty" db:"user_id"`
+	SubscriberId     string  `json:"subscriber_id,omitempty" db:"subscriber_id"`
+	NotifyBeforeDays int     `json:"notify_before_days,omitempty" db:"notify_before_days"`
+	MutedUntil       *string `json:"muted_until,omitempty" db:"muted_until"`
+}
+
+// SubscriptionDetails is a subscription as seen by the subscriber
+type SubscriptionDetails struct {
+	User              User    `json:"user" db:"user"`
+	NotifyBeforeDays  int     `json:"notify_before_days" db:"notify_before_days"`
+	MutedUntil        *string `json:"muted_until,omitempty" db:"muted_until"`
+	NextBirthday      string  `json:"next_birthday" db:"next_birthday"`
+	DaysUntilBirthday int     `json:"days_until_birthday" db:"days_until_birthday"`
+}
+
+// SubscriptionUpdate describes a partial update of a subscription. nil fields are left unchanged,
+// muted_until is changed only when SetMutedUntil is true, so it can be cleared with a nil MutedUntil.
+type SubscriptionUpdate struct {
+	NotifyBeforeDays *int
+	SetMutedUntil    bool
+	MutedUntil       *time.Time
+}
+
+const (
+	SubscriptionStatusCreated    = "created"
+	SubscriptionStatusDeleted    = "deleted"
+	SubscriptionStatusDuplicate  = "duplicate"
+	SubscriptionStatusNotFound   = "not_found"
+	SubscriptionStatusRolledBack = "rolled_back"
+)
+
+type NewSubscription struct {
+	UserId           string
+	NotifyBeforeDays int
+	MutedUntil       *time.Time
+}
+
+// SubscriptionResult is an outcome of a single item of a batch operation
+type SubscriptionResult struct {
+	UserId string `json:"user_id"`
+	Status string `json:"status"`
+}
+
+const (
+	SubscriptionRuleEveryone   = "everyone"
+	SubscriptionRuleDepartment = "department"
+	SubscriptionRuleOffice     = "office"
+)
+
+// SubscriptionRule subscribes to every user matching the rule, including the ones who sign up later
+type SubscriptionRule struct {
+	Rule             string    `json:"rule" db:"rule"`
+	NotifyBeforeDays int       `json:"notify_before_days" db:"notify_before_days"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 }
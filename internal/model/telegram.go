@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+type TelegramLinkCode struct {
+	Code      string    `json:"code" db:"code"`
+	UserId    string    `json:"-" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// RefreshToken is an issued refresh token, tokens rotated from each other share the session.
+// id of the token is also the id of the access token issued along with it
+type RefreshToken struct {
+	Id        string     `db:"id"`
+	UserId    string     `db:"user_id"`
+	SessionId string     `db:"session_id"`
+	RotatedAt *time.Time `db:"rotated_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
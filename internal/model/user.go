@@ -1,10 +1,62 @@
 package model
 
+import "time"
+
 type User struct {
-	Id           string `json:"id,omitempty" db:"id"`
-	Email        string `json:"email,omitempty" db:"email"`
-	PasswordHash string `json:"password_hash,omitempty" db:"password_hash"`
-	Name         string `json:"name,omitempty" db:"name"`
-	Surname      string `json:"surname,omitempty" db:"surname"`
-	BirthdayDate string `json:"birthday_date,omitempty" db:"birthday_date"`
+	Id           string  `json:"id,omitempty" db:"id"`
+	Email        string  `json:"email,omitempty" db:"email"`
+	PasswordHash string  `json:"password_hash,omitempty" db:"password_hash"`
+	Name         string  `json:"name,omitempty" db:"name"`
+	Surname      string  `json:"surname,omitempty" db:"surname"`
+	BirthdayDate string  `json:"birthday_date,omitempty" db:"birthday_date"`
+	ChatHandle   *string `json:"chat_handle,omitempty" db:"chat_handle"`
+	Phone        *string `json:"phone,omitempty" db:"phone"`
+	Department   *string `json:"department,omitempty" db:"department"`
+	Office       *string `json:"office,omitempty" db:"office"`
+	// ConsentedAt is the time the user agreed to personal data processing
+	ConsentedAt *time.Time `json:"consented_at,omitempty" db:"consented_at"`
+	// DeletionRequestedAt is set while the account waits to be purged
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty" db:"deletion_requested_at"`
+	// EmailVerifiedAt is empty until the user follows the link sent to the email,
+	// no reminders are sent to unverified email
+	EmailVerifiedAt    *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	VerificationSentAt *time.Time `json:"-" db:"verification_sent_at"`
+}
+
+// UserUpdate holds profile fields to change, nil fields are left as they are
+type UserUpdate struct {
+	Name         *string
+	Surname      *string
+	BirthdayDate *time.Time
+	Email        *string
+}
+
+const (
+	UserSortName         = "name"
+	UserSortNextBirthday = "next_birthday"
+)
+
+// DirectoryUser is a user listed in the directory
+type DirectoryUser struct {
+	User
+	NextBirthday string `json:"next_birthday" db:"next_birthday"`
+}
+
+// AccountExport is the personal data of the user, returned on request.
+// linked telegram chat is one of the channels, secrets and push encryption keys are left out
+type AccountExport struct {
+	ExportedAt        time.Time             `json:"exported_at"`
+	Profile           User                  `json:"profile"`
+	Privacy           Privacy               `json:"privacy"`
+	Vacation          *Vacation             `json:"vacation"`
+	Channels          []UserChannel         `json:"channels"`
+	Subscriptions     []SubscriptionDetails `json:"subscriptions"`
+	SubscriptionRules []SubscriptionRule    `json:"subscription_rules"`
+	Subscribers       []User                `json:"subscribers"`
+	Notifications     []InboxNotification   `json:"notifications"`
+	ReminderStates    []ReminderState       `json:"reminder_states"`
+	Webhooks          []Webhook             `json:"webhooks"`
+	WebhookDeliveries []WebhookDelivery     `json:"webhook_deliveries"`
+	ChatWebhooks      []ChatWebhook         `json:"chat_webhooks"`
+	PushSubscriptions []PushSubscription    `json:"push_subscriptions"`
 }
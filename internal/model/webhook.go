@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	EventBirthdayUpcoming    = "birthday.upcoming"
+	EventBirthdayToday       = "birthday.today"
+	EventUserCreated         = "user.created"
+	EventSubscriptionCreated = "subscription.created"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+type Webhook struct {
+	Id        string         `json:"id" db:"id"`
+	OwnerId   string         `json:"-" db:"owner_id"`
+	Url       string         `json:"url" db:"url"`
+	Secret    string         `json:"secret,omitempty" db:"secret"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	Enabled   bool           `json:"enabled" db:"enabled"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+type WebhookDelivery struct {
+	Id             string          `json:"id" db:"id"`
+	WebhookId      string          `json:"webhook_id" db:"webhook_id"`
+	RedeliveryOf   *string         `json:"redelivery_of,omitempty" db:"redelivery_of"`
+	Event          string          `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	Error          string          `json:"error,omitempty" db:"error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+type WebhookEvent struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type Birthday struct {
+	UserId            string `json:"user_id" db:"user_id"`
+	Name              string `json:"name" db:"name"`
+	Surname           string `json:"surname" db:"surname"`
+	BirthdayDate      string `json:"birthday_date" db:"birthday_date"`
+	DaysUntilBirthday int    `json:"days_until_birthday" db:"days_until_birthday"`
+}
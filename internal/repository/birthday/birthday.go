@@ -0,0 +1,99 @@
+package birthday
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+)
+
+type birthdayRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *birthdayRepository {
+	return &birthdayRepository{
+		db: db,
+	}
+}
+
+// subscribedQuery tells whether the user $1 gets reminders about the user u,
+// either by an explicit subscription or by a subscription rule
+const subscribedQuery = `
+	SELECT EXISTS (
+		SELECT 1 FROM subscriptions s WHERE s.user_id = u.id AND s.subscriber_id = $1
+	) OR EXISTS (
+		SELECT 1 FROM subscription_rules sr
+		JOIN users su on su.id = sr.subscriber_id
+		WHERE sr.subscriber_id = $1 AND (
+			sr.rule = 'everyone'
+			OR (sr.rule = 'department' AND u.department = su.department)
+			OR (sr.rule = 'office' AND u.office = su.office)
+		)
+	) subscribed
+`
+
+func (r *birthdayRepository) FindUpcomingBirthdays(
+	ctx context.Context, userId, scope string, days int,
+) ([]model.UserBirthday, error) {
+	var birthdays []model.UserBirthday
+	// next birthday is this year's one unless it has already passed, so the window wraps over the new year.
+	// february 29 birthdays fall on february 28 in non-leap years, same as in reminders
+	query := `
+		SELECT u.id "user.id", u.name "user.name", u.surname "user.surname",
+		u.department "user.department", u.office "user.office",
+		n.next_birthday::text date, n.next_birthday - CURRENT_DATE days_until_birthday,
+		CASE WHEN NOT COALESCE(us.hide_age, false)
+			THEN (DATE_PART('year', n.next_birthday) - DATE_PART('year', u.birthday_date))::integer
+		END age,
+		sub.subscribed subscribed
+		FROM users u
+		LEFT JOIN user_settings us on us.user_id = u.id
+		CROSS JOIN LATERAL (` + subscribedQuery + `) sub
+		CROSS JOIN LATERAL (
+			SELECT (DATE_PART('year', CURRENT_DATE) - DATE_PART('year', u.birthday_date))::integer years
+		) a
+		CROSS JOIN LATERAL (
+			SELECT CASE
+				WHEN (u.birthday_date + make_interval(years => a.years))::date >= CURRENT_DATE
+				THEN (u.birthday_date + make_interval(years => a.years))::date
+				ELSE (u.birthday_date + make_interval(years => a.years + 1))::date
+			END next_birthday
+		) n
+		WHERE u.id != $1 AND u.deletion_requested_at IS NULL AND ($2 = 'all' OR sub.subscribed)
+		AND n.next_birthday <= CURRENT_DATE + $3::integer
+		ORDER BY n.next_birthday, u.surname, u.name;
+	`
+	err := r.db.SelectContext(ctx, &birthdays, query, userId, scope, days)
+	return birthdays, err
+}
+
+func (r *birthdayRepository) FindMonthBirthdays(
+	ctx context.Context, userId, scope string, year, month int,
+) ([]model.UserBirthday, error) {
+	var birthdays []model.UserBirthday
+	// february 29 birthdays fall on february 28 in non-leap years, same as in reminders
+	query := `
+		SELECT u.id "user.id", u.name "user.name", u.surname "user.surname",
+		u.department "user.department", u.office "user.office",
+		o.occurrence::text date, o.occurrence - CURRENT_DATE days_until_birthday,
+		CASE WHEN NOT COALESCE(us.hide_age, false)
+			THEN $3::integer - DATE_PART('year', u.birthday_date)::integer
+		END age,
+		sub.subscribed subscribed
+		FROM users u
+		LEFT JOIN user_settings us on us.user_id = u.id
+		CROSS JOIN LATERAL (` + subscribedQuery + `) sub
+		CROSS JOIN LATERAL (
+			SELECT (u.birthday_date + make_interval(
+				years => $3::integer - DATE_PART('year', u.birthday_date)::integer
+			))::date occurrence
+		) o
+		WHERE u.id != $1 AND u.deletion_requested_at IS NULL AND ($2 = 'all' OR sub.subscribed)
+		AND DATE_PART('year', u.birthday_date) < $3::integer
+		AND DATE_PART('month', o.occurrence) = $4::integer
+		ORDER BY o.occurrence, u.surname, u.name;
+	`
+	err := r.db.SelectContext(ctx, &birthdays, query, userId, scope, year, month)
+	return birthdays, err
+}
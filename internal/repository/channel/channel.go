@@ -0,0 +1,120 @@
+package channel
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type channelRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *channelRepository {
+	return &channelRepository{
+		db: db,
+	}
+}
+
+func (r *channelRepository) FindUserChannels(ctx context.Context, userId string) ([]model.UserChannel, error) {
+	var channels []model.UserChannel
+	query := "SELECT channel, address, enabled FROM user_channels WHERE user_id = $1 ORDER BY channel;"
+	err := r.db.SelectContext(ctx, &channels, query, userId)
+	return channels, err
+}
+
+func (r *channelRepository) SaveUserChannel(ctx context.Context, userId, channel, address string, enabled bool) error {
+	query := `
+		INSERT INTO user_channels (user_id, channel, address, enabled) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, channel) DO UPDATE SET address = EXCLUDED.address, enabled = EXCLUDED.enabled;
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, channel, address, enabled)
+	return err
+}
+
+func (r *channelRepository) DisableEmailChannel(ctx context.Context, userId string) error {
+	// implicit email channel bound to the signup address is turned into an explicit disabled one
+	query := `
+		INSERT INTO user_channels (user_id, channel, address, enabled)
+		SELECT id, 'email', email, false FROM users WHERE id = $1
+		ON CONFLICT (user_id, channel) DO UPDATE SET enabled = false;
+	`
+	result, err := r.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *channelRepository) DeleteUserChannel(ctx context.Context, userId, channel string) error {
+	query := "DELETE FROM user_channels WHERE user_id = $1 AND channel = $2;"
+	result, err := r.db.ExecContext(ctx, query, userId, channel)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrChannelNotFound
+	}
+	return nil
+}
+
+func (r *channelRepository) FindSubscriptionChannels(
+	ctx context.Context, userId, subscriberId string,
+) ([]model.SubscriptionChannel, error) {
+	var channels []model.SubscriptionChannel
+	query := `
+		SELECT user_id, channel, address, enabled FROM subscription_channels
+		WHERE user_id = $1 AND subscriber_id = $2 ORDER BY channel;
+	`
+	err := r.db.SelectContext(ctx, &channels, query, userId, subscriberId)
+	return channels, err
+}
+
+func (r *channelRepository) SaveSubscriptionChannel(
+	ctx context.Context, userId, subscriberId, channel string, address *string, enabled bool,
+) error {
+	query := `
+		INSERT INTO subscription_channels (user_id, subscriber_id, channel, address, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, subscriber_id, channel) DO UPDATE
+		SET address = EXCLUDED.address, enabled = EXCLUDED.enabled;
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, subscriberId, channel, address, enabled)
+	if err, ok := err.(*pq.Error); ok {
+		// check foreign key constraint violation
+		if err.Code == "23503" {
+			return repository.ErrSubscriptionNotFound
+		}
+	}
+	return err
+}
+
+func (r *channelRepository) DeleteSubscriptionChannel(ctx context.Context, userId, subscriberId, channel string) error {
+	query := "DELETE FROM subscription_channels WHERE user_id = $1 AND subscriber_id = $2 AND channel = $3;"
+	result, err := r.db.ExecContext(ctx, query, userId, subscriberId, channel)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrChannelNotFound
+	}
+	return nil
+}
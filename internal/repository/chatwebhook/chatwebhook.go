@@ -0,0 +1,130 @@
+package chatwebhook
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type chatWebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *chatWebhookRepository {
+	return &chatWebhookRepository{
+		db: db,
+	}
+}
+
+func (r *chatWebhookRepository) CreateWebhook(ctx context.Context, webhook model.ChatWebhook) (string, error) {
+	var id string
+	query := `
+		INSERT INTO chat_webhooks (owner_id, name, url, format, announce_today, remind_before_days, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
+	`
+	row := r.db.QueryRowxContext(
+		ctx, query,
+		webhook.OwnerId, webhook.Name, webhook.Url, webhook.Format,
+		webhook.AnnounceToday, webhook.RemindBeforeDays, webhook.Enabled,
+	)
+	err := row.Scan(&id)
+	return id, err
+}
+
+func (r *chatWebhookRepository) FindWebhooks(ctx context.Context, ownerId string) ([]model.ChatWebhook, error) {
+	var webhooks []model.ChatWebhook
+	query := "SELECT * FROM chat_webhooks WHERE owner_id = $1 ORDER BY name;"
+	err := r.db.SelectContext(ctx, &webhooks, query, ownerId)
+	return webhooks, err
+}
+
+func (r *chatWebhookRepository) DeleteWebhook(ctx context.Context, id, ownerId string) error {
+	query := "DELETE FROM chat_webhooks WHERE id = $1 AND owner_id = $2;"
+	result, err := r.db.ExecContext(ctx, query, id, ownerId)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrChatWebhookNotFound
+	}
+	return nil
+}
+
+func (r *chatWebhookRepository) GetLock(ctx context.Context) (*sqlx.Tx, error) {
+	opts := &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  true,
+	}
+	tx, err := r.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, "LOCK TABLE ONLY chat_webhooks IN SHARE UPDATE EXCLUSIVE MODE NOWAIT;")
+	if err != nil {
+		return nil, repository.ErrLockTaken
+	}
+	return tx, nil
+}
+
+// FindAnnouncements returns birthdays of users the webhook owner is subscribed to, explicitly or by a rule,
+// so a webhook never receives birthdays its owner does not get reminders about
+func (r *chatWebhookRepository) FindAnnouncements(ctx context.Context, tx *sqlx.Tx) ([]model.Announcement, error) {
+	var announcements []model.Announcement
+	// birthday is moved to the year of the compared date, so february 29 falls on february 28 in non-leap years.
+	// muted subscriptions are left out, and they take precedence over rules as in reminders
+	query := `
+		WITH audience AS (
+			SELECT w.id webhook_id, s.user_id user_id
+			FROM chat_webhooks w
+			JOIN subscriptions s on s.subscriber_id = w.owner_id
+			WHERE s.muted_until IS NULL OR s.muted_until < CURRENT_DATE
+			UNION
+			SELECT w.id webhook_id, u.id user_id
+			FROM chat_webhooks w
+			JOIN subscription_rules sr on sr.subscriber_id = w.owner_id
+			JOIN users su on su.id = w.owner_id
+			JOIN users u on u.id != w.owner_id AND (
+				sr.rule = 'everyone'
+				OR (sr.rule = 'department' AND u.department = su.department)
+				OR (sr.rule = 'office' AND u.office = su.office)
+			)
+			WHERE NOT EXISTS (
+				SELECT 1 FROM subscriptions s WHERE s.user_id = u.id AND s.subscriber_id = w.owner_id
+			)
+		),
+		occurrences AS (
+			SELECT a.webhook_id webhook_id, a.user_id user_id,
+			(u.birthday_date + make_interval(
+				years => (DATE_PART('year', CURRENT_DATE) - DATE_PART('year', u.birthday_date))::integer
+			))::date = CURRENT_DATE is_today,
+			(u.birthday_date + make_interval(
+				years => (
+					DATE_PART('year', CURRENT_DATE + w.remind_before_days) - DATE_PART('year', u.birthday_date)
+				)::integer
+			))::date = CURRENT_DATE + w.remind_before_days is_upcoming
+			FROM audience a
+			JOIN chat_webhooks w on w.id = a.webhook_id
+			JOIN users u on u.id = a.user_id
+		)
+		SELECT w.id webhook_id, w.url webhook_url, w.format webhook_format,
+		u.id user_id, u.name user_name, u.surname user_surname, u.chat_handle user_chat_handle,
+		u.birthday_date birthday_date,
+		CASE WHEN o.is_today THEN 0 ELSE w.remind_before_days END days_until_birthday
+		FROM chat_webhooks w
+		JOIN occurrences o on o.webhook_id = w.id
+		JOIN users u on u.id = o.user_id
+		JOIN users uo on uo.id = w.owner_id
+		WHERE w.enabled AND u.deletion_requested_at IS NULL AND uo.deletion_requested_at IS NULL
+		AND ((w.announce_today AND o.is_today) OR (w.remind_before_days > 0 AND o.is_upcoming))
+		ORDER BY w.id, days_until_birthday, u.surname, u.name;
+	`
+	err := tx.SelectContext(ctx, &announcements, query)
+	return announcements, err
+}
@@ -0,0 +1,121 @@
+package inbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type inboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *inboxRepository {
+	return &inboxRepository{
+		db: db,
+	}
+}
+
+func (r *inboxRepository) CreateNotifications(ctx context.Context, notifications []model.InboxNotification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO inbox_notifications (user_id, birthday_user_id, subject, body)
+		VALUES (:user_id, :birthday_user_id, :subject, :body);
+	`
+	_, err := r.db.NamedExecContext(ctx, query, notifications)
+	return err
+}
+
+func (r *inboxRepository) FindNotifications(
+	ctx context.Context, userId, cursor string, unreadOnly bool, limit int,
+) ([]model.InboxNotification, error) {
+	if cursor != "" {
+		// a cursor which is unknown or belongs to another user would silently yield an empty page
+		var exists bool
+		query := "SELECT EXISTS (SELECT 1 FROM inbox_notifications WHERE id = $1 AND user_id = $2);"
+		if err := r.db.GetContext(ctx, &exists, query, cursor, userId); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, repository.ErrInboxCursorNotFound
+		}
+	}
+
+	var notifications []model.InboxNotification
+	// cursor is the id of the last notification on the previous page,
+	// rows are compared by (created_at, id) pair, since created_at is not unique
+	query := `
+		SELECT * FROM inbox_notifications
+		WHERE user_id = $1 AND (NOT $3 OR read_at IS NULL)
+		AND ($2 = '' OR (created_at, id) < (
+			SELECT created_at, id FROM inbox_notifications WHERE id = NULLIF($2, '')::uuid AND user_id = $1
+		))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4;
+	`
+	err := r.db.SelectContext(ctx, &notifications, query, userId, cursor, unreadOnly, limit)
+	return notifications, err
+}
+
+func (r *inboxRepository) FindAllNotifications(ctx context.Context, userId string) ([]model.InboxNotification, error) {
+	var notifications []model.InboxNotification
+	query := "SELECT * FROM inbox_notifications WHERE user_id = $1 ORDER BY created_at DESC, id DESC;"
+	err := r.db.SelectContext(ctx, &notifications, query, userId)
+	return notifications, err
+}
+
+func (r *inboxRepository) CountUnread(ctx context.Context, userId string) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM inbox_notifications WHERE user_id = $1 AND read_at IS NULL;"
+	err := r.db.GetContext(ctx, &count, query, userId)
+	return count, err
+}
+
+func (r *inboxRepository) MarkRead(ctx context.Context, userId string, ids []string) (int64, error) {
+	// already read notifications keep their original read time, but are still counted as found
+	query := `
+		UPDATE inbox_notifications SET read_at = COALESCE(read_at, now())
+		WHERE user_id = $1 AND id = ANY($2::uuid[]);
+	`
+	result, err := r.db.ExecContext(ctx, query, userId, pq.StringArray(ids))
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, repository.ErrQueryResultUnknown
+	}
+	return count, nil
+}
+
+func (r *inboxRepository) MarkAllRead(ctx context.Context, userId string) (int64, error) {
+	query := "UPDATE inbox_notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL;"
+	result, err := r.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, repository.ErrQueryResultUnknown
+	}
+	return count, nil
+}
+
+func (r *inboxRepository) DeleteNotificationsBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := "DELETE FROM inbox_notifications WHERE created_at < $1;"
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, repository.ErrQueryResultUnknown
+	}
+	return count, nil
+}
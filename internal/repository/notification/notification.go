@@ -35,19 +35,177 @@ func (r *notificationRepository) GetLock(ctx context.Context) (*sqlx.Tx, error)
 	return tx, nil
 }
 
+// dueRemindersQuery selects reminders due today into the reminders table expression,
+// with one row per recipient and birthday, regardless of the channels of the recipient
+const dueRemindersQuery = `
+		-- the next birthday within a week, february 29 falls on february 28 in non-leap years
+		WITH birthdays AS (
+			SELECT u.id user_id, n.occurrence occurrence
+			FROM users u
+			CROSS JOIN LATERAL (
+				SELECT (DATE_PART('year', CURRENT_DATE) - DATE_PART('year', u.birthday_date))::integer years
+			) a
+			CROSS JOIN LATERAL (
+				SELECT CASE
+					WHEN (u.birthday_date + make_interval(years => a.years))::date >= CURRENT_DATE
+					THEN (u.birthday_date + make_interval(years => a.years))::date
+					ELSE (u.birthday_date + make_interval(years => a.years + 1))::date
+				END occurrence
+			) n
+			WHERE n.occurrence <= CURRENT_DATE + 7
+		),
+		-- users matching subscription rules, explicit subscriptions and their mutes take precedence,
+		-- and the rule with the earliest reminder wins when several of them match
+		rule_matches AS (
+			SELECT DISTINCT ON (u.id, sr.subscriber_id)
+			u.id user_id, sr.subscriber_id subscriber_id, sr.notify_before_days notify_before_days,
+			sr.rule rule, b.occurrence occurrence
+			FROM subscription_rules sr
+			JOIN users su on su.id = sr.subscriber_id
+			JOIN users u on u.id != sr.subscriber_id AND (
+				sr.rule = 'everyone'
+				OR (sr.rule = 'department' AND u.department = su.department)
+				OR (sr.rule = 'office' AND u.office = su.office)
+			)
+			JOIN birthdays b on b.user_id = u.id
+			WHERE NOT EXISTS (
+				SELECT 1 FROM subscriptions s WHERE s.user_id = u.id AND s.subscriber_id = sr.subscriber_id
+			)
+			ORDER BY u.id, sr.subscriber_id, sr.notify_before_days DESC
+		),
+		-- reminders on the configured day before birthday and on the day they were snoozed to,
+		-- unless the subscription is muted
+		due AS (
+			SELECT s.user_id user_id, s.subscriber_id subscriber_id, s.notify_before_days days_until_birthday,
+			b.occurrence occurrence, NULL::text subscription_rule
+			FROM subscriptions s
+			JOIN birthdays b on b.user_id = s.user_id
+			WHERE b.occurrence = CURRENT_DATE + s.notify_before_days
+			AND (s.muted_until IS NULL OR s.muted_until < CURRENT_DATE)
+			UNION
+			SELECT rm.user_id user_id, rm.subscriber_id subscriber_id, rm.notify_before_days days_until_birthday,
+			rm.occurrence occurrence, rm.rule subscription_rule
+			FROM rule_matches rm
+			WHERE rm.occurrence = CURRENT_DATE + rm.notify_before_days
+			UNION
+			SELECT rs.user_id user_id, rs.subscriber_id subscriber_id,
+			rs.occurrence - CURRENT_DATE days_until_birthday, rs.occurrence occurrence, NULL::text subscription_rule
+			FROM reminder_states rs
+			JOIN subscriptions s on s.user_id = rs.user_id AND s.subscriber_id = rs.subscriber_id
+			WHERE rs.snoozed_until = CURRENT_DATE AND rs.occurrence >= CURRENT_DATE
+			AND (s.muted_until IS NULL OR s.muted_until < CURRENT_DATE)
+		),
+		on_vacation AS (
+			SELECT user_id, vacation_delegate_id delegate_id FROM user_settings
+			WHERE CURRENT_DATE BETWEEN vacation_from AND vacation_until
+		),
+		-- reminders of subscribers on vacation are forwarded to their delegate, unless the delegate
+		-- is on vacation too or gets own reminders about this user
+		recipients AS (
+			SELECT d.*, d.subscriber_id recipient_id, NULL::uuid forwarded_from_id FROM due d
+			WHERE NOT EXISTS (SELECT 1 FROM on_vacation v WHERE v.user_id = d.subscriber_id)
+			UNION ALL (
+				SELECT DISTINCT ON (d.user_id, v.delegate_id, d.occurrence)
+				d.*, v.delegate_id recipient_id, d.subscriber_id forwarded_from_id
+				FROM due d
+				JOIN on_vacation v on v.user_id = d.subscriber_id
+				WHERE v.delegate_id IS NOT NULL AND v.delegate_id != d.user_id
+				AND NOT EXISTS (SELECT 1 FROM on_vacation dv WHERE dv.user_id = v.delegate_id)
+				AND NOT EXISTS (
+					SELECT 1 FROM subscriptions s WHERE s.user_id = d.user_id AND s.subscriber_id = v.delegate_id
+				)
+				AND NOT EXISTS (
+					SELECT 1 FROM rule_matches rm WHERE rm.user_id = d.user_id AND rm.subscriber_id = v.delegate_id
+				)
+				ORDER BY d.user_id, v.delegate_id, d.occurrence, d.subscriber_id
+			)
+		),
+		-- users waiting for account deletion neither get reminders nor are reminded about,
+		-- and birthdays already congratulated are not reminded of again
+		reminders AS (
+			SELECT r.recipient_id subscriber_id,
+			u1.id birthday_user_id, u1.name birthday_user_name, u1.surname birthday_user_surname,
+			u1.birthday_date birthday_date, r.occurrence birthday_occurrence,
+			r.days_until_birthday days_until_birthday, r.forwarded_from_id forwarded_from_id,
+			uf.name forwarded_from_name, uf.surname forwarded_from_surname, r.subscription_rule subscription_rule
+			FROM recipients r
+			JOIN users u1 on u1.id = r.user_id
+			JOIN users ur on ur.id = r.recipient_id
+			LEFT JOIN users uf on uf.id = r.forwarded_from_id
+			WHERE u1.deletion_requested_at IS NULL AND ur.deletion_requested_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM reminder_states rs
+				WHERE rs.user_id = r.user_id AND rs.subscriber_id = r.subscriber_id
+				AND rs.occurrence = r.occurrence AND rs.congratulated_at IS NOT NULL
+			)
+		)
+`
+
+// FindUsersToNotify returns reminders due today, one row for every enabled channel of the recipient
 func (r *notificationRepository) FindUsersToNotify(ctx context.Context, tx *sqlx.Tx) ([]model.Notification, error) {
 	var notifications []model.Notification
-	query := `
-		SELECT u2.email subscriber_email, u1.id birthday_user_id, u1.name birthday_user_name,
-		u1.surname birthday_user_surname, u1.birthday_date birthday_date, s.notify_before_days days_until_birthday
-		FROM subscriptions s
-		LEFT JOIN users u1 on u1.id = s.user_id
-		LEFT JOIN users u2 on u2.id = s.subscriber_id
-		WHERE DATE_PART('day', u1.birthday_date) >= DATE_PART('day', CURRENT_DATE)
-		AND DATE_PART('day', u1.birthday_date) <= DATE_PART('day', CURRENT_DATE) + 7
-		AND DATE_PART('day', u1.birthday_date) = DATE_PART('day', CURRENT_DATE) + s.notify_before_days
-		AND DATE_PART('month', u1.birthday_date) = DATE_PART('month', CURRENT_DATE);
+	query := dueRemindersQuery + `,
+		-- signup email is an implicit enabled channel and profile phone an implicit disabled one,
+		-- every browser push subscription is a separate webpush address
+		channels AS (
+			SELECT u.id user_id, 'email' channel, u.email address, true enabled FROM users u
+			WHERE NOT EXISTS (
+				SELECT 1 FROM user_channels uc
+				WHERE uc.user_id = u.id AND uc.channel = 'email'
+			)
+			UNION ALL
+			SELECT u.id user_id, 'sms' channel, u.phone address, false enabled FROM users u
+			WHERE u.phone IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM user_channels uc
+				WHERE uc.user_id = u.id AND uc.channel = 'sms'
+			)
+			UNION ALL
+			SELECT user_id, 'webpush' channel, id::text address, true enabled FROM push_subscriptions
+			UNION ALL
+			SELECT user_id, channel, address, enabled FROM user_channels
+			WHERE channel != 'webpush'
+		)
+		SELECT r.subscriber_id, c.channel channel,
+		CASE WHEN c.channel = 'email' THEN ur.email ELSE COALESCE(sc.address, c.address) END address,
+		r.birthday_user_id, r.birthday_user_name, r.birthday_user_surname, r.birthday_date, r.birthday_occurrence,
+		r.days_until_birthday, r.forwarded_from_id, r.forwarded_from_name, r.forwarded_from_surname,
+		r.subscription_rule
+		FROM reminders r
+		JOIN users ur on ur.id = r.subscriber_id
+		JOIN channels c on c.user_id = r.subscriber_id
+		LEFT JOIN subscription_channels sc on sc.user_id = r.birthday_user_id
+			AND sc.subscriber_id = r.subscriber_id
+			AND sc.channel = c.channel
+		-- email reminders go only to the verified signup address
+		WHERE COALESCE(sc.enabled, c.enabled)
+		AND (c.channel != 'email' OR ur.email_verified_at IS NOT NULL);
 	`
 	err := tx.SelectContext(ctx, &notifications, query)
 	return notifications, err
 }
+
+// FindDueReminders returns reminders due today without channels, so that inbox entries
+// are created even for subscribers who disabled every channel
+func (r *notificationRepository) FindDueReminders(ctx context.Context, tx *sqlx.Tx) ([]model.Notification, error) {
+	var notifications []model.Notification
+	query := dueRemindersQuery + `
+		SELECT subscriber_id, birthday_user_id, birthday_user_name, birthday_user_surname, birthday_date,
+		birthday_occurrence, days_until_birthday, forwarded_from_id, forwarded_from_name, forwarded_from_surname,
+		subscription_rule
+		FROM reminders;
+	`
+	err := tx.SelectContext(ctx, &notifications, query)
+	return notifications, err
+}
+
+func (r *notificationRepository) SaveDeliveries(ctx context.Context, deliveries []model.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO notification_deliveries (birthday_user_id, subscriber_id, channel, address, status, error)
+		VALUES (:birthday_user_id, :subscriber_id, :channel, :address, :status, :error);
+	`
+	_, err := r.db.NamedExecContext(ctx, query, deliveries)
+	return err
+}
@@ -0,0 +1,51 @@
+package passwordreset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type passwordResetRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *passwordResetRepository {
+	return &passwordResetRepository{
+		db: db,
+	}
+}
+
+func (r *passwordResetRepository) CreateToken(
+	ctx context.Context, userId, tokenHash string, expiresAt time.Time,
+) error {
+	// previously issued tokens of the user are invalidated
+	query := `
+		WITH deleted AS (DELETE FROM password_reset_tokens WHERE user_id = $1)
+		INSERT INTO password_reset_tokens (token_hash, user_id, expires_at) VALUES ($2, $1, $3);
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, tokenHash, expiresAt)
+	if err, ok := err.(*pq.Error); ok {
+		// check unique constraint violation
+		if err.Code == "23505" {
+			return repository.ErrResetTokenIsNotUnique
+		}
+	}
+	return err
+}
+
+// ConsumeToken removes the token, so it can be used only once
+func (r *passwordResetRepository) ConsumeToken(ctx context.Context, tokenHash string) (string, error) {
+	var userId string
+	query := "DELETE FROM password_reset_tokens WHERE token_hash = $1 AND expires_at > now() RETURNING user_id;"
+	err := r.db.QueryRowxContext(ctx, query, tokenHash).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repository.ErrResetTokenNotFound
+	}
+	return userId, err
+}
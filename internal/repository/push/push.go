@@ -0,0 +1,75 @@
+package push
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type pushRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *pushRepository {
+	return &pushRepository{
+		db: db,
+	}
+}
+
+func (r *pushRepository) SaveSubscription(
+	ctx context.Context, userId, endpoint, p256dh, auth string,
+) (model.PushSubscription, error) {
+	var subscription model.PushSubscription
+	// the same browser may be signed in by another user, the subscription is moved to them
+	query := `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (endpoint) DO UPDATE
+		SET user_id = EXCLUDED.user_id, p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth
+		RETURNING *;
+	`
+	err := r.db.GetContext(ctx, &subscription, query, userId, endpoint, p256dh, auth)
+	return subscription, err
+}
+
+func (r *pushRepository) FindSubscription(ctx context.Context, id, userId string) (model.PushSubscription, error) {
+	var subscription model.PushSubscription
+	query := "SELECT * FROM push_subscriptions WHERE id = $1 AND user_id = $2;"
+	err := r.db.GetContext(ctx, &subscription, query, id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return subscription, repository.ErrPushSubscriptionNotFound
+	}
+	return subscription, err
+}
+
+func (r *pushRepository) FindSubscriptions(ctx context.Context, userId string) ([]model.PushSubscription, error) {
+	var subscriptions []model.PushSubscription
+	query := "SELECT * FROM push_subscriptions WHERE user_id = $1 ORDER BY created_at;"
+	err := r.db.SelectContext(ctx, &subscriptions, query, userId)
+	return subscriptions, err
+}
+
+func (r *pushRepository) DeleteSubscription(ctx context.Context, id string) error {
+	query := "DELETE FROM push_subscriptions WHERE id = $1;"
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *pushRepository) DeleteUserSubscription(ctx context.Context, userId, endpoint string) error {
+	query := "DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2;"
+	result, err := r.db.ExecContext(ctx, query, userId, endpoint)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrPushSubscriptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package refreshtoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type refreshTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *refreshTokenRepository {
+	return &refreshTokenRepository{
+		db: db,
+	}
+}
+
+// CreateToken starts a new session and returns id of the token, expired tokens of the user are cleaned up
+func (r *refreshTokenRepository) CreateToken(
+	ctx context.Context, userId, tokenHash string, expiresAt time.Time,
+) (string, error) {
+	var id string
+	query := `
+		WITH deleted AS (DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at <= now())
+		INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at)
+		VALUES ($1, gen_random_uuid(), $2, $3)
+		RETURNING id;
+	`
+	err := r.db.GetContext(ctx, &id, query, userId, tokenHash, expiresAt)
+	if err, ok := err.(*pq.Error); ok {
+		// check unique constraint violation
+		if err.Code == "23505" {
+			return "", repository.ErrRefreshTokenIsNotUnique
+		}
+	}
+	return id, err
+}
+
+// RotateToken replaces the token with a new one in the same session.
+// token which was already rotated or revoked is considered stolen, so the whole session is revoked
+func (r *refreshTokenRepository) RotateToken(
+	ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time,
+) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return token, err
+	}
+	// row is locked, so that concurrent requests with the same token cannot both rotate it
+	query := `
+		SELECT id, user_id, session_id, rotated_at, revoked_at FROM refresh_tokens
+		WHERE token_hash = $1 AND expires_at > now()
+		FOR UPDATE;
+	`
+	err = tx.GetContext(ctx, &token, query, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = tx.Rollback()
+		return token, repository.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return token, err
+	}
+
+	if token.RotatedAt != nil || token.RevokedAt != nil {
+		query = "UPDATE refresh_tokens SET revoked_at = now() WHERE session_id = $1 AND revoked_at IS NULL;"
+		if _, err := tx.ExecContext(ctx, query, token.SessionId); err != nil {
+			_ = tx.Rollback()
+			return token, err
+		}
+		if err := tx.Commit(); err != nil {
+			return token, err
+		}
+		return token, repository.ErrRefreshTokenReused
+	}
+
+	query = "UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1;"
+	if _, err := tx.ExecContext(ctx, query, token.Id); err != nil {
+		_ = tx.Rollback()
+		return token, err
+	}
+	var newToken model.RefreshToken
+	query = `
+		INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, session_id, rotated_at, revoked_at;
+	`
+	err = tx.GetContext(ctx, &newToken, query, token.UserId, token.SessionId, newTokenHash, expiresAt)
+	if err != nil {
+		_ = tx.Rollback()
+		if err, ok := err.(*pq.Error); ok && err.Code == "23505" {
+			return newToken, repository.ErrRefreshTokenIsNotUnique
+		}
+		return newToken, err
+	}
+	return newToken, tx.Commit()
+}
+
+func (r *refreshTokenRepository) FindToken(ctx context.Context, tokenId string) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	query := "SELECT id, user_id, session_id, rotated_at, revoked_at FROM refresh_tokens WHERE id = $1;"
+	err := r.db.GetContext(ctx, &token, query, tokenId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return token, repository.ErrRefreshTokenNotFound
+	}
+	return token, err
+}
+
+// RevokeSession revokes all tokens of the session the token belongs to
+func (r *refreshTokenRepository) RevokeSession(ctx context.Context, tokenId string) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE session_id = (SELECT session_id FROM refresh_tokens WHERE id = $1) AND revoked_at IS NULL;
+	`
+	result, err := r.db.ExecContext(ctx, query, tokenId)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrRefreshTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package reminder
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type reminderRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *reminderRepository {
+	return &reminderRepository{
+		db: db,
+	}
+}
+
+func (r *reminderRepository) Snooze(ctx context.Context, userId, subscriberId string, occurrence time.Time) error {
+	// reminder is moved to the next day, which is computed by the database as in the notification query
+	query := `
+		INSERT INTO reminder_states (user_id, subscriber_id, occurrence, snoozed_until)
+		SELECT $1, $2, $3, CURRENT_DATE + 1 WHERE CURRENT_DATE + 1 <= $3::date
+		ON CONFLICT (user_id, subscriber_id, occurrence) DO UPDATE SET snoozed_until = EXCLUDED.snoozed_until;
+	`
+	result, err := r.db.ExecContext(ctx, query, userId, subscriberId, occurrence)
+	if err, ok := err.(*pq.Error); ok {
+		// check foreign key constraint violation
+		if err.Code == "23503" {
+			return repository.ErrSubscriptionNotFound
+		}
+	}
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrSnoozeTooLate
+	}
+	return nil
+}
+
+func (r *reminderRepository) MarkCongratulated(
+	ctx context.Context, userId, subscriberId string, occurrence time.Time,
+) error {
+	// pending snooze is dropped, there is nothing to remind about anymore
+	query := `
+		INSERT INTO reminder_states (user_id, subscriber_id, occurrence, congratulated_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (user_id, subscriber_id, occurrence) DO UPDATE
+		SET congratulated_at = COALESCE(reminder_states.congratulated_at, now()), snoozed_until = NULL;
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, subscriberId, occurrence)
+	if err, ok := err.(*pq.Error); ok {
+		// check foreign key constraint violation
+		if err.Code == "23503" {
+			return repository.ErrSubscriptionNotFound
+		}
+	}
+	return err
+}
+
+func (r *reminderRepository) FindStates(ctx context.Context, subscriberId string) ([]model.ReminderState, error) {
+	var states []model.ReminderState
+	query := `
+		SELECT user_id, occurrence::text occurrence, snoozed_until::text snoozed_until, congratulated_at
+		FROM reminder_states
+		WHERE subscriber_id = $1 ORDER BY occurrence DESC, user_id;
+	`
+	err := r.db.SelectContext(ctx, &states, query, subscriberId)
+	return states, err
+}
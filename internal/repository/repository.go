@@ -10,33 +10,200 @@ import (
 )
 
 var (
-	ErrLockTaken               = errors.New("lock is already taken")
-	ErrEmailIsNotUnique        = errors.New("email is not unique")
-	ErrUserNotFound            = errors.New("user not found")
-	ErrSubscriptionIsNotUnique = errors.New("subscription is not unique")
-	ErrSubscriptionNotFound    = errors.New("subscription not found")
-	ErrQueryResultUnknown      = errors.New("query result unknown")
+	ErrLockTaken                = errors.New("lock is already taken")
+	ErrEmailIsNotUnique         = errors.New("email is not unique")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrSubscriptionIsNotUnique  = errors.New("subscription is not unique")
+	ErrSubscriptionNotFound     = errors.New("subscription not found")
+	ErrQueryResultUnknown       = errors.New("query result unknown")
+	ErrChannelNotFound          = errors.New("channel not found")
+	ErrLinkCodeIsNotUnique      = errors.New("link code is not unique")
+	ErrLinkCodeNotFound         = errors.New("link code not found")
+	ErrChatWebhookNotFound      = errors.New("chat webhook not found")
+	ErrWebhookNotFound          = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound  = errors.New("webhook delivery not found")
+	ErrSmsLimitExceeded         = errors.New("sms daily limit exceeded")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+	ErrSnoozeTooLate            = errors.New("snooze is too late")
+	ErrVacationNotFound         = errors.New("vacation not found")
+	ErrSubscriptionRuleNotFound = errors.New("subscription rule not found")
+	ErrVerificationNotAllowed   = errors.New("verification email cannot be sent")
+	ErrResetTokenIsNotUnique    = errors.New("password reset token is not unique")
+	ErrResetTokenNotFound       = errors.New("password reset token not found")
+	ErrRefreshTokenIsNotUnique  = errors.New("refresh token is not unique")
+	ErrRefreshTokenNotFound     = errors.New("refresh token not found")
+	ErrRefreshTokenReused       = errors.New("refresh token is reused")
+	ErrInboxCursorNotFound      = errors.New("inbox cursor not found")
+	ErrUserCursorNotFound       = errors.New("user cursor not found")
 )
 
 type UserRepository interface {
-	CreateUser(ctx context.Context, email, name, surname, passwordHash string, birthdayDate time.Time) (string, error)
+	CreateUser(
+		ctx context.Context, email, name, surname, passwordHash string, birthdayDate time.Time, emailVerified bool,
+	) (string, error)
 	FindByEmail(ctx context.Context, email string) (model.User, error)
-	FindAllUsers(ctx context.Context, userId string) ([]model.User, error)
-	FindUsersSubscribedTo(ctx context.Context, userId string) ([]model.User, error)
+	FindAllUsers(
+		ctx context.Context, userId string, search []string, sort, cursor string, limit int,
+	) ([]model.DirectoryUser, error)
+	FindById(ctx context.Context, userId string) (model.User, error)
+	FindProfile(ctx context.Context, userId string) (model.User, error)
+	FindUsersSubscribedTo(ctx context.Context, userId, sort string) ([]model.SubscriptionDetails, error)
+	FindSubscribers(ctx context.Context, userId string) ([]model.User, error)
+	UpdateChatHandle(ctx context.Context, userId string, chatHandle *string) error
+	UpdatePhone(ctx context.Context, userId string, phone *string) error
+	UpdateWorkplace(ctx context.Context, userId string, department, office *string) error
+	UpdateUser(ctx context.Context, userId string, update model.UserUpdate) (model.User, error)
+	UpdateEmail(ctx context.Context, userId, oldEmail, newEmail string) error
+	VerifyEmail(ctx context.Context, userId, email string) error
+	ReserveVerification(ctx context.Context, userId string, sentBefore time.Time) (model.User, error)
+	FindPasswordHash(ctx context.Context, userId string) (string, error)
+	UpdatePassword(ctx context.Context, userId, passwordHash string) error
+	ScheduleDeletion(ctx context.Context, userId string) (time.Time, error)
+	CancelDeletion(ctx context.Context, userId string) error
+	DeleteUsersScheduledBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type SubscriptionRepository interface {
-	CreateSubscription(ctx context.Context, userId, subscriberId string, notifyBeforeDays int) error
+	CreateSubscription(
+		ctx context.Context, userId, subscriberId string, notifyBeforeDays int, mutedUntil *time.Time,
+	) error
+	MuteSubscription(ctx context.Context, userId, subscriberId string, mutedUntil *time.Time) error
+	UpdateSubscription(
+		ctx context.Context, userId, subscriberId string, update model.SubscriptionUpdate,
+	) (model.Subscription, error)
 	DeleteSubscription(ctx context.Context, userId, subscriberId string) error
+	CreateSubscriptions(
+		ctx context.Context, subscriberId string, subscriptions []model.NewSubscription, atomic bool,
+	) ([]model.SubscriptionResult, error)
+	DeleteSubscriptions(
+		ctx context.Context, subscriberId string, userIds []string, atomic bool,
+	) ([]model.SubscriptionResult, error)
+	FindRules(ctx context.Context, subscriberId string) ([]model.SubscriptionRule, error)
+	SaveRule(ctx context.Context, subscriberId, rule string, notifyBeforeDays int) (model.SubscriptionRule, error)
+	DeleteRule(ctx context.Context, subscriberId, rule string) error
 }
 
 type NotificationRepository interface {
 	GetLock(ctx context.Context) (*sqlx.Tx, error)
 	FindUsersToNotify(ctx context.Context, tx *sqlx.Tx) ([]model.Notification, error)
+	FindDueReminders(ctx context.Context, tx *sqlx.Tx) ([]model.Notification, error)
+	SaveDeliveries(ctx context.Context, deliveries []model.Delivery) error
+}
+
+type ChannelRepository interface {
+	FindUserChannels(ctx context.Context, userId string) ([]model.UserChannel, error)
+	SaveUserChannel(ctx context.Context, userId, channel, address string, enabled bool) error
+	DisableEmailChannel(ctx context.Context, userId string) error
+	DeleteUserChannel(ctx context.Context, userId, channel string) error
+	FindSubscriptionChannels(ctx context.Context, userId, subscriberId string) ([]model.SubscriptionChannel, error)
+	SaveSubscriptionChannel(
+		ctx context.Context, userId, subscriberId, channel string, address *string, enabled bool,
+	) error
+	DeleteSubscriptionChannel(ctx context.Context, userId, subscriberId, channel string) error
+}
+
+type TelegramRepository interface {
+	CreateLinkCode(ctx context.Context, userId, code string, expiresAt time.Time) error
+	ConsumeLinkCode(ctx context.Context, code string) (string, error)
+}
+
+type PasswordResetRepository interface {
+	CreateToken(ctx context.Context, userId, tokenHash string, expiresAt time.Time) error
+	ConsumeToken(ctx context.Context, tokenHash string) (string, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateToken(ctx context.Context, userId, tokenHash string, expiresAt time.Time) (string, error)
+	RotateToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (model.RefreshToken, error)
+	FindToken(ctx context.Context, tokenId string) (model.RefreshToken, error)
+	RevokeSession(ctx context.Context, tokenId string) error
+}
+
+type ChatWebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook model.ChatWebhook) (string, error)
+	FindWebhooks(ctx context.Context, ownerId string) ([]model.ChatWebhook, error)
+	DeleteWebhook(ctx context.Context, id, ownerId string) error
+	GetLock(ctx context.Context) (*sqlx.Tx, error)
+	FindAnnouncements(ctx context.Context, tx *sqlx.Tx) ([]model.Announcement, error)
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error)
+	FindWebhooks(ctx context.Context, ownerId string) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id, ownerId string) error
+	FindWebhooksForEvent(ctx context.Context, event string, ownerIds []string) ([]model.Webhook, error)
+	FindSubscriberWebhooksForEvent(ctx context.Context, event, userId string) ([]model.Webhook, error)
+	FindWebhook(ctx context.Context, id string) (model.Webhook, error)
+	CreateDelivery(
+		ctx context.Context, webhookId, event string, payload []byte, redeliveryOf *string, nextAttemptAt time.Time,
+	) (model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+	ClaimDelivery(ctx context.Context, id string, leaseUntil time.Time) error
+	ClaimDueDeliveries(ctx context.Context, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error)
+	FindDeliveries(ctx context.Context, webhookId, ownerId, status string, limit int) ([]model.WebhookDelivery, error)
+	FindDelivery(ctx context.Context, id, ownerId string) (model.WebhookDelivery, error)
+	FindAllDeliveries(ctx context.Context, ownerId string) ([]model.WebhookDelivery, error)
+	GetLock(ctx context.Context) (*sqlx.Tx, error)
+	FindBirthdays(ctx context.Context, tx *sqlx.Tx, upcomingDays int) ([]model.Birthday, error)
+}
+
+type SmsRepository interface {
+	ReserveMessages(ctx context.Context, phone string, count, recipientLimit, totalLimit int) error
+}
+
+type PushRepository interface {
+	SaveSubscription(ctx context.Context, userId, endpoint, p256dh, auth string) (model.PushSubscription, error)
+	FindSubscription(ctx context.Context, id, userId string) (model.PushSubscription, error)
+	FindSubscriptions(ctx context.Context, userId string) ([]model.PushSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	DeleteUserSubscription(ctx context.Context, userId, endpoint string) error
+}
+
+type InboxRepository interface {
+	CreateNotifications(ctx context.Context, notifications []model.InboxNotification) error
+	FindNotifications(
+		ctx context.Context, userId, cursor string, unreadOnly bool, limit int,
+	) ([]model.InboxNotification, error)
+	FindAllNotifications(ctx context.Context, userId string) ([]model.InboxNotification, error)
+	CountUnread(ctx context.Context, userId string) (int, error)
+	MarkRead(ctx context.Context, userId string, ids []string) (int64, error)
+	MarkAllRead(ctx context.Context, userId string) (int64, error)
+	DeleteNotificationsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type ReminderRepository interface {
+	Snooze(ctx context.Context, userId, subscriberId string, occurrence time.Time) error
+	MarkCongratulated(ctx context.Context, userId, subscriberId string, occurrence time.Time) error
+	FindStates(ctx context.Context, subscriberId string) ([]model.ReminderState, error)
+}
+
+type SettingsRepository interface {
+	FindVacation(ctx context.Context, userId string) (model.Vacation, error)
+	SaveVacation(ctx context.Context, userId string, from, until time.Time, delegateId *string) error
+	DeleteVacation(ctx context.Context, userId string) error
+	FindPrivacy(ctx context.Context, userId string) (model.Privacy, error)
+	SavePrivacy(ctx context.Context, userId string, privacy model.Privacy) error
+}
+
+type BirthdayRepository interface {
+	FindUpcomingBirthdays(ctx context.Context, userId, scope string, days int) ([]model.UserBirthday, error)
+	FindMonthBirthdays(ctx context.Context, userId, scope string, year, month int) ([]model.UserBirthday, error)
 }
 
 type Repository struct {
-	User         UserRepository
-	Subscription SubscriptionRepository
-	Notification NotificationRepository
+	User          UserRepository
+	Subscription  SubscriptionRepository
+	Notification  NotificationRepository
+	Channel       ChannelRepository
+	Telegram      TelegramRepository
+	ChatWebhook   ChatWebhookRepository
+	Webhook       WebhookRepository
+	Sms           SmsRepository
+	Push          PushRepository
+	Inbox         InboxRepository
+	Reminder      ReminderRepository
+	Settings      SettingsRepository
+	Birthday      BirthdayRepository
+	PasswordReset PasswordResetRepository
+	RefreshToken  RefreshTokenRepository
 }
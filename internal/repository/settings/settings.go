@@ -0,0 +1,105 @@
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type settingsRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *settingsRepository {
+	return &settingsRepository{
+		db: db,
+	}
+}
+
+func (r *settingsRepository) FindVacation(ctx context.Context, userId string) (model.Vacation, error) {
+	var vacation model.Vacation
+	// ::text cast if for proper date display
+	query := `
+		SELECT vacation_from::text vacation_from, vacation_until::text vacation_until, vacation_delegate_id
+		FROM user_settings WHERE user_id = $1 AND vacation_from IS NOT NULL;
+	`
+	err := r.db.GetContext(ctx, &vacation, query, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return vacation, repository.ErrVacationNotFound
+	}
+	return vacation, err
+}
+
+func (r *settingsRepository) SaveVacation(
+	ctx context.Context, userId string, from, until time.Time, delegateId *string,
+) error {
+	query := `
+		INSERT INTO user_settings (user_id, vacation_from, vacation_until, vacation_delegate_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET vacation_from = EXCLUDED.vacation_from,
+		vacation_until = EXCLUDED.vacation_until, vacation_delegate_id = EXCLUDED.vacation_delegate_id;
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, from, until, delegateId)
+	if err, ok := err.(*pq.Error); ok {
+		// check foreign key constraint violation, user itself exists since request is authorized
+		if err.Code == "23503" {
+			return repository.ErrUserNotFound
+		}
+	}
+	return err
+}
+
+func (r *settingsRepository) DeleteVacation(ctx context.Context, userId string) error {
+	query := `
+		UPDATE user_settings SET vacation_from = NULL, vacation_until = NULL, vacation_delegate_id = NULL
+		WHERE user_id = $1 AND vacation_from IS NOT NULL;
+	`
+	result, err := r.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrVacationNotFound
+	}
+	return nil
+}
+
+func (r *settingsRepository) FindPrivacy(ctx context.Context, userId string) (model.Privacy, error) {
+	var privacy model.Privacy
+	query := "SELECT hide_subscriptions, notify_new_subscribers, hide_age FROM user_settings WHERE user_id = $1;"
+	err := r.db.GetContext(ctx, &privacy, query, userId)
+	// users without settings row have default settings
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Privacy{}, nil
+	}
+	return privacy, err
+}
+
+func (r *settingsRepository) SavePrivacy(ctx context.Context, userId string, privacy model.Privacy) error {
+	query := `
+		INSERT INTO user_settings (user_id, hide_subscriptions, notify_new_subscribers, hide_age)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET hide_subscriptions = EXCLUDED.hide_subscriptions,
+		notify_new_subscribers = EXCLUDED.notify_new_subscribers, hide_age = EXCLUDED.hide_age;
+	`
+	_, err := r.db.ExecContext(
+		ctx, query, userId, privacy.HideSubscriptions, privacy.NotifyNewSubscribers, privacy.HideAge,
+	)
+	if err, ok := err.(*pq.Error); ok {
+		// check foreign key constraint violation
+		if err.Code == "23503" {
+			return repository.ErrUserNotFound
+		}
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package sms
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+// usage of all recipients is accumulated under this key
+const totalUsageKey = "*"
+
+type smsRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *smsRepository {
+	return &smsRepository{
+		db: db,
+	}
+}
+
+func reserve(ctx context.Context, tx *sqlx.Tx, phone string, count, limit int) error {
+	var total int
+	query := `
+		INSERT INTO sms_daily_usage (phone, count) VALUES ($1, $2)
+		ON CONFLICT (day, phone) DO UPDATE SET count = sms_daily_usage.count + EXCLUDED.count
+		WHERE sms_daily_usage.count + EXCLUDED.count <= $3
+		RETURNING count;
+	`
+	err := tx.QueryRowxContext(ctx, query, phone, count, limit).Scan(&total)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrSmsLimitExceeded
+	}
+	if err != nil {
+		return err
+	}
+	// first insert of the day is not checked by ON CONFLICT clause
+	if total > limit {
+		return repository.ErrSmsLimitExceeded
+	}
+	return nil
+}
+
+func (r *smsRepository) ReserveMessages(
+	ctx context.Context, phone string, count, recipientLimit, totalLimit int,
+) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := reserve(ctx, tx, phone, count, recipientLimit); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := reserve(ctx, tx, totalUsageKey, count, totalLimit); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,10 +2,13 @@ package subscription
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
 )
 
@@ -19,9 +22,14 @@ func NewRepository(db *sqlx.DB) *subscriptionRepository {
 	}
 }
 
-func (r *subscriptionRepository) CreateSubscription(ctx context.Context, userId, subscriberId string, notifyBeforeDays int) error {
-	query := "INSERT INTO subscriptions (user_id, subscriber_id, notify_before_days) VALUES ($1, $2, $3);"
-	_, err := r.db.ExecContext(ctx, query, userId, subscriberId, notifyBeforeDays)
+func (r *subscriptionRepository) CreateSubscription(
+	ctx context.Context, userId, subscriberId string, notifyBeforeDays int, mutedUntil *time.Time,
+) error {
+	query := `
+		INSERT INTO subscriptions (user_id, subscriber_id, notify_before_days, muted_until)
+		VALUES ($1, $2, $3, $4);
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, subscriberId, notifyBeforeDays, mutedUntil)
 	if err, ok := err.(*pq.Error); ok {
 		// check foreign key constraint violation
 		if err.Code == "23503" {
@@ -35,6 +43,47 @@ func (r *subscriptionRepository) CreateSubscription(ctx context.Context, userId,
 	return err
 }
 
+func (r *subscriptionRepository) MuteSubscription(
+	ctx context.Context, userId, subscriberId string, mutedUntil *time.Time,
+) error {
+	query := "UPDATE subscriptions SET muted_until = $3 WHERE user_id = $1 AND subscriber_id = $2;"
+	result, err := r.db.ExecContext(ctx, query, userId, subscriberId, mutedUntil)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) UpdateSubscription(
+	ctx context.Context, userId, subscriberId string, update model.SubscriptionUpdate,
+) (model.Subscription, error) {
+	var subscription model.Subscription
+	// single statement keeps concurrent partial updates from overwriting each other's fields
+	query := `
+		UPDATE subscriptions SET
+		notify_before_days = COALESCE($3::integer, notify_before_days),
+		muted_until = CASE WHEN $4::boolean THEN $5::date ELSE muted_until END
+		WHERE user_id = $1 AND subscriber_id = $2
+		RETURNING user_id, subscriber_id, notify_before_days,
+		CASE WHEN muted_until >= CURRENT_DATE THEN muted_until::text END muted_until;
+	`
+	err := r.db.GetContext(
+		ctx, &subscription, query,
+		userId, subscriberId, update.NotifyBeforeDays, update.SetMutedUntil, update.MutedUntil,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Subscription{}, repository.ErrSubscriptionNotFound
+	}
+	return subscription, err
+}
+
 func (r *subscriptionRepository) DeleteSubscription(ctx context.Context, userId, subscriberId string) error {
 	query := "DELETE FROM subscriptions WHERE user_id=$1 AND subscriber_id=$2;"
 	result, err := r.db.ExecContext(ctx, query, userId, subscriberId)
@@ -50,3 +99,145 @@ func (r *subscriptionRepository) DeleteSubscription(ctx context.Context, userId,
 	}
 	return nil
 }
+
+// CreateSubscriptions creates subscriptions one by one in a single transaction. duplicates and missing users
+// are reported per item instead of failing the statement, so the transaction stays usable after them.
+// in atomic mode nothing is created unless every item succeeds.
+func (r *subscriptionRepository) CreateSubscriptions(
+	ctx context.Context, subscriberId string, subscriptions []model.NewSubscription, atomic bool,
+) ([]model.SubscriptionResult, error) {
+	query := `
+		WITH target AS (
+			SELECT id FROM users WHERE id = $1
+		),
+		created AS (
+			INSERT INTO subscriptions (user_id, subscriber_id, notify_before_days, muted_until)
+			SELECT id, $2, $3, $4 FROM target
+			ON CONFLICT (user_id, subscriber_id) DO NOTHING
+			RETURNING user_id
+		)
+		SELECT CASE
+			WHEN EXISTS (SELECT 1 FROM created) THEN 'created'
+			WHEN EXISTS (SELECT 1 FROM target) THEN 'duplicate'
+			ELSE 'not_found'
+		END;
+	`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]model.SubscriptionResult, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		var status string
+		err := tx.GetContext(
+			ctx, &status, query,
+			subscription.UserId, subscriberId, subscription.NotifyBeforeDays, subscription.MutedUntil,
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		results = append(results, model.SubscriptionResult{UserId: subscription.UserId, Status: status})
+	}
+	return results, commitResults(tx, results, model.SubscriptionStatusCreated, atomic)
+}
+
+// DeleteSubscriptions deletes subscriptions one by one in a single transaction.
+// in atomic mode nothing is deleted unless every subscription exists.
+func (r *subscriptionRepository) DeleteSubscriptions(
+	ctx context.Context, subscriberId string, userIds []string, atomic bool,
+) ([]model.SubscriptionResult, error) {
+	query := "DELETE FROM subscriptions WHERE user_id = $1 AND subscriber_id = $2;"
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]model.SubscriptionResult, 0, len(userIds))
+	for _, userId := range userIds {
+		result, err := tx.ExecContext(ctx, query, userId, subscriberId)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, repository.ErrQueryResultUnknown
+		}
+		status := model.SubscriptionStatusDeleted
+		if count == 0 {
+			status = model.SubscriptionStatusNotFound
+		}
+		results = append(results, model.SubscriptionResult{UserId: userId, Status: status})
+	}
+	return results, commitResults(tx, results, model.SubscriptionStatusDeleted, atomic)
+}
+
+// commitResults commits the batch, or rolls it back in atomic mode if any item failed.
+// succeeded items of a rolled back batch are reported as rolled back.
+func commitResults(tx *sqlx.Tx, results []model.SubscriptionResult, success string, atomic bool) error {
+	failed := false
+	for _, result := range results {
+		if result.Status != success {
+			failed = true
+			break
+		}
+	}
+	if !atomic || !failed {
+		return tx.Commit()
+	}
+	if err := tx.Rollback(); err != nil {
+		return err
+	}
+	for i := range results {
+		if results[i].Status == success {
+			results[i].Status = model.SubscriptionStatusRolledBack
+		}
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) FindRules(ctx context.Context, subscriberId string) ([]model.SubscriptionRule, error) {
+	var rules []model.SubscriptionRule
+	query := `
+		SELECT rule, notify_before_days, created_at FROM subscription_rules
+		WHERE subscriber_id = $1 ORDER BY rule;
+	`
+	err := r.db.SelectContext(ctx, &rules, query, subscriberId)
+	return rules, err
+}
+
+func (r *subscriptionRepository) SaveRule(
+	ctx context.Context, subscriberId, rule string, notifyBeforeDays int,
+) (model.SubscriptionRule, error) {
+	var subscriptionRule model.SubscriptionRule
+	query := `
+		INSERT INTO subscription_rules (subscriber_id, rule, notify_before_days) VALUES ($1, $2, $3)
+		ON CONFLICT (subscriber_id, rule) DO UPDATE SET notify_before_days = EXCLUDED.notify_before_days
+		RETURNING rule, notify_before_days, created_at;
+	`
+	err := r.db.GetContext(ctx, &subscriptionRule, query, subscriberId, rule, notifyBeforeDays)
+	if err, ok := err.(*pq.Error); ok {
+		// check foreign key constraint violation
+		if err.Code == "23503" {
+			return model.SubscriptionRule{}, repository.ErrUserNotFound
+		}
+	}
+	return subscriptionRule, err
+}
+
+func (r *subscriptionRepository) DeleteRule(ctx context.Context, subscriberId, rule string) error {
+	query := "DELETE FROM subscription_rules WHERE subscriber_id = $1 AND rule = $2;"
+	result, err := r.db.ExecContext(ctx, query, subscriberId, rule)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrSubscriptionRuleNotFound
+	}
+	return nil
+}
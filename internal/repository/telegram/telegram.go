@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type telegramRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *telegramRepository {
+	return &telegramRepository{
+		db: db,
+	}
+}
+
+func (r *telegramRepository) CreateLinkCode(ctx context.Context, userId, code string, expiresAt time.Time) error {
+	// previously issued codes of the user are invalidated
+	query := `
+		WITH deleted AS (DELETE FROM telegram_link_codes WHERE user_id = $1)
+		INSERT INTO telegram_link_codes (code, user_id, expires_at) VALUES ($2, $1, $3);
+	`
+	_, err := r.db.ExecContext(ctx, query, userId, code, expiresAt)
+	if err, ok := err.(*pq.Error); ok {
+		// check unique constraint violation
+		if err.Code == "23505" {
+			return repository.ErrLinkCodeIsNotUnique
+		}
+	}
+	return err
+}
+
+func (r *telegramRepository) ConsumeLinkCode(ctx context.Context, code string) (string, error) {
+	var userId string
+	query := "DELETE FROM telegram_link_codes WHERE code = $1 AND expires_at > now() RETURNING user_id;"
+	err := r.db.QueryRowxContext(ctx, query, code).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repository.ErrLinkCodeNotFound
+	}
+	return userId, err
+}
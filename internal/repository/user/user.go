@@ -2,6 +2,9 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -21,14 +24,20 @@ func NewRepository(db *sqlx.DB) *userRepository {
 }
 
 func (r *userRepository) CreateUser(
-	ctx context.Context, email, name, surname, passwordHash string, birthdayDate time.Time,
+	ctx context.Context, email, name, surname, passwordHash string, birthdayDate time.Time, emailVerified bool,
 ) (string, error) {
 	var id string
+	// verification email is sent right after signup, so it counts as sent for the resend limit
 	query := `
-		INSERT INTO users (email, password_hash, name, surname, birthday_date)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id;
+		INSERT INTO users (
+			email, password_hash, name, surname, birthday_date, consented_at, email_verified_at, verification_sent_at
+		)
+		VALUES (
+			$1, $2, $3, $4, $5, now(), CASE WHEN $6 THEN now() END, CASE WHEN NOT $6 THEN now() END
+		)
+		RETURNING id;
 	`
-	row := r.db.QueryRowxContext(ctx, query, email, passwordHash, name, surname, birthdayDate)
+	row := r.db.QueryRowxContext(ctx, query, email, passwordHash, name, surname, birthdayDate, emailVerified)
 	err := row.Scan(&id)
 	if err, ok := err.(*pq.Error); ok {
 		// check unique constraint violation
@@ -43,26 +52,350 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (model.U
 	var user model.User
 	query := "SELECT * FROM users WHERE email=$1;"
 	err := r.db.GetContext(ctx, &user, query, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, repository.ErrUserNotFound
+	}
 	return user, err
 }
 
-func (r *userRepository) FindAllUsers(ctx context.Context, userId string) ([]model.User, error) {
-	var users []model.User
-	// ::text cast if for proper date display
-	query := "SELECT id, name, surname, birthday_date::text FROM users WHERE id != $1;"
-	err := r.db.SelectContext(ctx, &users, query, userId)
+func (r *userRepository) FindById(ctx context.Context, userId string) (model.User, error) {
+	var user model.User
+	query := `
+		SELECT id, name, surname, birthday_date::text, department, office FROM users WHERE id = $1;
+	`
+	err := r.db.GetContext(ctx, &user, query, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, repository.ErrUserNotFound
+	}
+	return user, err
+}
+
+// profileColumns are the columns of the user's own profile, password hash is never returned
+const profileColumns = `id, email, name, surname, birthday_date::text, chat_handle, phone, department, office,
+	consented_at, deletion_requested_at, email_verified_at`
+
+func (r *userRepository) FindProfile(ctx context.Context, userId string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM users WHERE id = $1;", profileColumns)
+	err := r.db.GetContext(ctx, &user, query, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, repository.ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *userRepository) UpdateUser(
+	ctx context.Context, userId string, update model.UserUpdate,
+) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf(`
+		UPDATE users SET
+			name = COALESCE($2, name),
+			surname = COALESCE($3, surname),
+			birthday_date = COALESCE($4, birthday_date)
+		WHERE id = $1
+		RETURNING %s;
+	`, profileColumns)
+	err := r.db.GetContext(ctx, &user, query, userId, update.Name, update.Surname, update.BirthdayDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, repository.ErrUserNotFound
+	}
+	return user, err
+}
+
+// UpdateEmail changes email only if it was not changed since the confirmation was requested,
+// new email is verified by the confirmation itself
+func (r *userRepository) UpdateEmail(ctx context.Context, userId, oldEmail, newEmail string) error {
+	query := `
+		UPDATE users SET email = $3, email_verified_at = now(), verification_sent_at = NULL
+		WHERE id = $1 AND email = $2;
+	`
+	result, err := r.db.ExecContext(ctx, query, userId, oldEmail, newEmail)
+	if err, ok := err.(*pq.Error); ok {
+		if err.Code == "23505" {
+			return repository.ErrEmailIsNotUnique
+		}
+	}
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+// VerifyEmail verifies email only if it was not changed since the verification link was sent
+func (r *userRepository) VerifyEmail(ctx context.Context, userId, email string) error {
+	query := `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, now())
+		WHERE id = $1 AND email = $2;
+	`
+	result, err := r.db.ExecContext(ctx, query, userId, email)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+// ReserveVerification marks verification email as sent, unless the email is already verified
+// or the previous one was sent after sentBefore
+func (r *userRepository) ReserveVerification(
+	ctx context.Context, userId string, sentBefore time.Time,
+) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf(`
+		UPDATE users SET verification_sent_at = now()
+		WHERE id = $1 AND email_verified_at IS NULL
+		AND (verification_sent_at IS NULL OR verification_sent_at < $2)
+		RETURNING %s;
+	`, profileColumns)
+	err := r.db.GetContext(ctx, &user, query, userId, sentBefore)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, repository.ErrVerificationNotAllowed
+	}
+	return user, err
+}
+
+func (r *userRepository) FindPasswordHash(ctx context.Context, userId string) (string, error) {
+	var passwordHash string
+	query := "SELECT password_hash FROM users WHERE id = $1;"
+	err := r.db.GetContext(ctx, &passwordHash, query, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return passwordHash, repository.ErrUserNotFound
+	}
+	return passwordHash, err
+}
+
+// UpdatePassword also invalidates password reset tokens and revokes all sessions of the user
+func (r *userRepository) UpdatePassword(ctx context.Context, userId, passwordHash string) error {
+	query := `
+		WITH deleted AS (DELETE FROM password_reset_tokens WHERE user_id = $1),
+		revoked AS (UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL)
+		UPDATE users SET password_hash = $2 WHERE id = $1;
+	`
+	result, err := r.db.ExecContext(ctx, query, userId, passwordHash)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+// ScheduleDeletion keeps the time of the first request, so repeated requests do not postpone the purge
+func (r *userRepository) ScheduleDeletion(ctx context.Context, userId string) (time.Time, error) {
+	var requestedAt time.Time
+	query := `
+		UPDATE users SET deletion_requested_at = COALESCE(deletion_requested_at, now())
+		WHERE id = $1
+		RETURNING deletion_requested_at;
+	`
+	err := r.db.GetContext(ctx, &requestedAt, query, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return requestedAt, repository.ErrUserNotFound
+	}
+	return requestedAt, err
+}
+
+func (r *userRepository) CancelDeletion(ctx context.Context, userId string) error {
+	query := "UPDATE users SET deletion_requested_at = NULL WHERE id = $1;"
+	result, err := r.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+// DeleteUsersScheduledBefore purges users, all their data is removed by cascade
+func (r *userRepository) DeleteUsersScheduledBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE deletion_requested_at < $1;"
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// directoryOrders maps sort to the columns the directory is ordered and paged by, id makes the order unique
+var directoryOrders = map[string]string{
+	model.UserSortName:         "surname, name, id",
+	model.UserSortNextBirthday: "next_birthday, surname, name, id",
+}
+
+func (r *userRepository) FindAllUsers(
+	ctx context.Context, userId string, search []string, sort, cursor string, limit int,
+) ([]model.DirectoryUser, error) {
+	if cursor != "" {
+		// the caller, deleted and unknown users are not in the directory, so the page would silently be empty
+		var exists bool
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM users WHERE id = $1 AND id != $2 AND deletion_requested_at IS NULL
+			);
+		`
+		if err := r.db.GetContext(ctx, &exists, query, cursor, userId); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, repository.ErrUserCursorNotFound
+		}
+	}
+
+	var users []model.DirectoryUser
+	order, ok := directoryOrders[sort]
+	if !ok {
+		order = directoryOrders[model.UserSortName]
+	}
+	// every search word has to be found in the name or the surname, both are compared
+	// by transliteration insensitive keys. cursor is the id of the last user on the previous page,
+	// it is looked up among all users, so the page does not break if the user stops matching the search
+	query := fmt.Sprintf(`
+		WITH directory AS (
+			SELECT u.id, u.name, u.surname, u.birthday_date::text birthday_date, u.department, u.office,
+			n.next_birthday, NOT EXISTS (
+				SELECT 1 FROM unnest($2::text[]) w
+				WHERE strpos(name_search_key(u.name || ' ' || u.surname), name_search_key(w)) = 0
+			) matches
+			FROM users u
+			CROSS JOIN LATERAL (
+				SELECT (DATE_PART('year', CURRENT_DATE) - DATE_PART('year', u.birthday_date))::integer years
+			) a
+			CROSS JOIN LATERAL (
+				SELECT CASE
+					WHEN (u.birthday_date + make_interval(years => a.years))::date >= CURRENT_DATE
+					THEN (u.birthday_date + make_interval(years => a.years))::date
+					ELSE (u.birthday_date + make_interval(years => a.years + 1))::date
+				END next_birthday
+			) n
+			WHERE u.id != $1 AND u.deletion_requested_at IS NULL
+		)
+		SELECT id, name, surname, birthday_date, department, office, next_birthday::text next_birthday
+		FROM directory
+		WHERE matches AND ($3 = '' OR (%[1]s) > (
+			SELECT %[1]s FROM directory WHERE id = NULLIF($3, '')::uuid
+		))
+		ORDER BY %[1]s
+		LIMIT $4;
+	`, order)
+	err := r.db.SelectContext(ctx, &users, query, userId, pq.Array(search), cursor, limit)
 	return users, err
 }
 
-func (r *userRepository) FindUsersSubscribedTo(ctx context.Context, userId string) ([]model.User, error) {
+func (r *userRepository) FindUsersSubscribedTo(
+	ctx context.Context, userId, sort string,
+) ([]model.SubscriptionDetails, error) {
+	var subscriptions []model.SubscriptionDetails
+	// mute state is only reported while it is still in effect.
+	// next birthday is this year's one unless it has already passed, february 29 birthdays
+	// fall on february 28 in non-leap years, same as in reminders
+	query := `
+		SELECT u.id "user.id", u.name "user.name", u.surname "user.surname",
+		u.birthday_date::text "user.birthday_date", u.department "user.department", u.office "user.office",
+		s.notify_before_days notify_before_days,
+		CASE WHEN s.muted_until >= CURRENT_DATE THEN s.muted_until::text END muted_until,
+		n.next_birthday::text next_birthday, n.next_birthday - CURRENT_DATE days_until_birthday
+		FROM subscriptions s
+		JOIN users u on u.id = s.user_id
+		CROSS JOIN LATERAL (
+			SELECT (DATE_PART('year', CURRENT_DATE) - DATE_PART('year', u.birthday_date))::integer years
+		) a
+		CROSS JOIN LATERAL (
+			SELECT CASE
+				WHEN (u.birthday_date + make_interval(years => a.years))::date >= CURRENT_DATE
+				THEN (u.birthday_date + make_interval(years => a.years))::date
+				ELSE (u.birthday_date + make_interval(years => a.years + 1))::date
+			END next_birthday
+		) n
+		WHERE s.subscriber_id = $1
+		ORDER BY CASE WHEN $2 = 'next_birthday' THEN n.next_birthday END, u.surname, u.name;
+	`
+	err := r.db.SelectContext(ctx, &subscriptions, query, userId, sort)
+	return subscriptions, err
+}
+
+func (r *userRepository) FindSubscribers(ctx context.Context, userId string) ([]model.User, error) {
 	var users []model.User
+	// subscribers who hide their subscriptions are left out
 	query := `
-		SELECT id, name, surname, birthday_date FROM users
-		WHERE id in (
-			SELECT user_id FROM subscriptions
-			WHERE subscriber_id = $1
-		);
+		SELECT u.id, u.name, u.surname, u.birthday_date::text FROM users u
+		JOIN subscriptions s on s.subscriber_id = u.id
+		LEFT JOIN user_settings us on us.user_id = u.id
+		WHERE s.user_id = $1 AND NOT COALESCE(us.hide_subscriptions, false)
+		AND u.deletion_requested_at IS NULL
+		ORDER BY u.surname, u.name;
 	`
 	err := r.db.SelectContext(ctx, &users, query, userId)
 	return users, err
 }
+
+func (r *userRepository) UpdateChatHandle(ctx context.Context, userId string, chatHandle *string) error {
+	query := "UPDATE users SET chat_handle = $2 WHERE id = $1;"
+	result, err := r.db.ExecContext(ctx, query, userId, chatHandle)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdatePhone(ctx context.Context, userId string, phone *string) error {
+	query := "UPDATE users SET phone = $2 WHERE id = $1;"
+	result, err := r.db.ExecContext(ctx, query, userId, phone)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateWorkplace(ctx context.Context, userId string, department, office *string) error {
+	query := "UPDATE users SET department = $2, office = $3 WHERE id = $1;"
+	result, err := r.db.ExecContext(ctx, query, userId, department, office)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *webhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	query := `
+		INSERT INTO webhooks (owner_id, url, secret, events) VALUES ($1, $2, $3, $4)
+		RETURNING id, owner_id, url, secret, events, enabled, created_at;
+	`
+	var created model.Webhook
+	err := r.db.GetContext(ctx, &created, query, webhook.OwnerId, webhook.Url, webhook.Secret, webhook.Events)
+	return created, err
+}
+
+func (r *webhookRepository) FindWebhooks(ctx context.Context, ownerId string) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	// secret is only returned once, on webhook creation
+	query := `
+		SELECT id, url, events, enabled, created_at FROM webhooks
+		WHERE owner_id = $1 ORDER BY created_at;
+	`
+	err := r.db.SelectContext(ctx, &webhooks, query, ownerId)
+	return webhooks, err
+}
+
+func (r *webhookRepository) DeleteWebhook(ctx context.Context, id, ownerId string) error {
+	query := "DELETE FROM webhooks WHERE id = $1 AND owner_id = $2;"
+	result, err := r.db.ExecContext(ctx, query, id, ownerId)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) FindWebhooksForEvent(
+	ctx context.Context, event string, ownerIds []string,
+) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	query := "SELECT * FROM webhooks WHERE enabled AND $1 = ANY(events) AND owner_id = ANY($2::uuid[]);"
+	err := r.db.SelectContext(ctx, &webhooks, query, event, pq.StringArray(ownerIds))
+	return webhooks, err
+}
+
+// FindSubscriberWebhooksForEvent returns webhooks of users subscribed to the user, explicitly or by a rule
+func (r *webhookRepository) FindSubscriberWebhooksForEvent(
+	ctx context.Context, event, userId string,
+) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	query := `
+		SELECT w.* FROM webhooks w
+		WHERE w.enabled AND $1 = ANY(w.events) AND w.owner_id != $2 AND (
+			EXISTS (SELECT 1 FROM subscriptions s WHERE s.user_id = $2 AND s.subscriber_id = w.owner_id)
+			OR EXISTS (
+				SELECT 1 FROM subscription_rules sr
+				JOIN users su on su.id = sr.subscriber_id
+				JOIN users u on u.id = $2
+				WHERE sr.subscriber_id = w.owner_id AND (
+					sr.rule = 'everyone'
+					OR (sr.rule = 'department' AND u.department = su.department)
+					OR (sr.rule = 'office' AND u.office = su.office)
+				)
+			)
+		);
+	`
+	err := r.db.SelectContext(ctx, &webhooks, query, event, userId)
+	return webhooks, err
+}
+
+func (r *webhookRepository) FindWebhook(ctx context.Context, id string) (model.Webhook, error) {
+	var webhook model.Webhook
+	query := "SELECT * FROM webhooks WHERE id = $1;"
+	err := r.db.GetContext(ctx, &webhook, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook, repository.ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (r *webhookRepository) CreateDelivery(
+	ctx context.Context, webhookId, event string, payload []byte, redeliveryOf *string, nextAttemptAt time.Time,
+) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, redelivery_of, next_attempt_at)
+		VALUES ($1, $2, $3::jsonb, $4, $5) RETURNING *;
+	`
+	err := r.db.GetContext(ctx, &delivery, query, webhookId, event, string(payload), redeliveryOf, nextAttemptAt)
+	return delivery, err
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, error = $5, next_attempt_at = $6, updated_at = now()
+		WHERE id = $1;
+	`
+	_, err := r.db.ExecContext(
+		ctx, query,
+		delivery.Id, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.Error,
+		delivery.NextAttemptAt,
+	)
+	return err
+}
+
+// ClaimDelivery reserves the due pending delivery for the next attempt,
+// so it is not sent by several processes at the same time
+func (r *webhookRepository) ClaimDelivery(ctx context.Context, id string, leaseUntil time.Time) error {
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = $2
+		WHERE id = $1 AND status = 'pending' AND next_attempt_at <= now();
+	`
+	result, err := r.db.ExecContext(ctx, query, id, leaseUntil)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return repository.ErrQueryResultUnknown
+	}
+	if count == 0 {
+		return repository.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// ClaimDueDeliveries reserves due pending deliveries of enabled webhooks, same as ClaimDelivery
+func (r *webhookRepository) ClaimDueDeliveries(
+	ctx context.Context, leaseUntil time.Time, limit int,
+) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND w.enabled
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING *;
+	`
+	err := r.db.SelectContext(ctx, &deliveries, query, leaseUntil, limit)
+	return deliveries, err
+}
+
+func (r *webhookRepository) FindDeliveries(
+	ctx context.Context, webhookId, ownerId, status string, limit int,
+) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	query := `
+		SELECT d.* FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.webhook_id = $1 AND w.owner_id = $2 AND ($3 = '' OR d.status = $3)
+		ORDER BY d.created_at DESC
+		LIMIT $4;
+	`
+	err := r.db.SelectContext(ctx, &deliveries, query, webhookId, ownerId, status, limit)
+	return deliveries, err
+}
+
+// FindAllDeliveries returns deliveries of all webhooks of the owner, it is used for the personal data export
+func (r *webhookRepository) FindAllDeliveries(ctx context.Context, ownerId string) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	query := `
+		SELECT d.* FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE w.owner_id = $1
+		ORDER BY d.created_at DESC;
+	`
+	err := r.db.SelectContext(ctx, &deliveries, query, ownerId)
+	return deliveries, err
+}
+
+func (r *webhookRepository) FindDelivery(ctx context.Context, id, ownerId string) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	query := `
+		SELECT d.* FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.id = $1 AND w.owner_id = $2;
+	`
+	err := r.db.GetContext(ctx, &delivery, query, id, ownerId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivery, repository.ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func (r *webhookRepository) GetLock(ctx context.Context) (*sqlx.Tx, error) {
+	opts := &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  true,
+	}
+	tx, err := r.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, "LOCK TABLE ONLY webhooks IN SHARE UPDATE EXCLUSIVE MODE NOWAIT;")
+	if err != nil {
+		return nil, repository.ErrLockTaken
+	}
+	return tx, nil
+}
+
+func (r *webhookRepository) FindBirthdays(
+	ctx context.Context, tx *sqlx.Tx, upcomingDays int,
+) ([]model.Birthday, error) {
+	var birthdays []model.Birthday
+	// birthday is moved to the year of the compared date, so february 29 falls on february 28 in non-leap years.
+	// year of birth is left out for users hiding their age
+	query := `
+		SELECT o.user_id user_id, o.name name, o.surname surname,
+		CASE WHEN COALESCE(us.hide_age, false)
+			THEN TO_CHAR(o.birthday_date, '--MM-DD') ELSE o.birthday_date::text
+		END birthday_date,
+		CASE WHEN o.is_today THEN 0 ELSE $1 END days_until_birthday
+		FROM (
+			SELECT u.id user_id, u.name name, u.surname surname, u.birthday_date birthday_date,
+			(u.birthday_date + make_interval(
+				years => (DATE_PART('year', CURRENT_DATE) - DATE_PART('year', u.birthday_date))::integer
+			))::date = CURRENT_DATE is_today,
+			(u.birthday_date + make_interval(
+				years => (DATE_PART('year', CURRENT_DATE + $1::integer) - DATE_PART('year', u.birthday_date))::integer
+			))::date = CURRENT_DATE + $1::integer is_upcoming
+			FROM users u
+			WHERE u.deletion_requested_at IS NULL
+		) o
+		LEFT JOIN user_settings us on us.user_id = o.user_id
+		WHERE o.is_today OR o.is_upcoming;
+	`
+	err := tx.SelectContext(ctx, &birthdays, query, upcomingDays)
+	return birthdays, err
+}
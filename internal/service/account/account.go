@@ -0,0 +1,167 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type accountService struct {
+	userRepository         repository.UserRepository
+	subscriptionRepository repository.SubscriptionRepository
+	channelRepository      repository.ChannelRepository
+	settingsRepository     repository.SettingsRepository
+	inboxRepository        repository.InboxRepository
+	reminderRepository     repository.ReminderRepository
+	webhookRepository      repository.WebhookRepository
+	chatWebhookRepository  repository.ChatWebhookRepository
+	pushRepository         repository.PushRepository
+	gracePeriod            time.Duration
+	logger                 *slog.Logger
+}
+
+func NewAccountService(
+	userRepository repository.UserRepository,
+	subscriptionRepository repository.SubscriptionRepository,
+	channelRepository repository.ChannelRepository,
+	settingsRepository repository.SettingsRepository,
+	inboxRepository repository.InboxRepository,
+	reminderRepository repository.ReminderRepository,
+	webhookRepository repository.WebhookRepository,
+	chatWebhookRepository repository.ChatWebhookRepository,
+	pushRepository repository.PushRepository,
+	gracePeriod time.Duration,
+	logger *slog.Logger,
+) *accountService {
+	return &accountService{
+		userRepository:         userRepository,
+		subscriptionRepository: subscriptionRepository,
+		channelRepository:      channelRepository,
+		settingsRepository:     settingsRepository,
+		inboxRepository:        inboxRepository,
+		reminderRepository:     reminderRepository,
+		webhookRepository:      webhookRepository,
+		chatWebhookRepository:  chatWebhookRepository,
+		pushRepository:         pushRepository,
+		gracePeriod:            gracePeriod,
+		logger:                 logger,
+	}
+}
+
+// ScheduleDeletion returns the time after which the account is purged,
+// until then the deletion can be cancelled
+func (s *accountService) ScheduleDeletion(ctx context.Context, userId, password string) (time.Time, error) {
+	passwordHash, err := s.userRepository.FindPasswordHash(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return time.Time{}, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during finding password hash", slog.String("error", err.Error()))
+		return time.Time{}, errors.New("failed to delete account")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		return time.Time{}, service.ErrInvalidPassword
+	}
+
+	requestedAt, err := s.userRepository.ScheduleDeletion(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return time.Time{}, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during scheduling account deletion", slog.String("error", err.Error()))
+		return time.Time{}, errors.New("failed to delete account")
+	}
+	return requestedAt.Add(s.gracePeriod), nil
+}
+
+func (s *accountService) CancelDeletion(ctx context.Context, userId string) error {
+	err := s.userRepository.CancelDeletion(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrUserNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during cancelling account deletion", slog.String("error", err.Error()))
+		return errors.New("failed to cancel account deletion")
+	}
+	return nil
+}
+
+func (s *accountService) Export(ctx context.Context, userId string) (model.AccountExport, error) {
+	export := model.AccountExport{ExportedAt: time.Now().UTC()}
+	failed := func(step string, err error) (model.AccountExport, error) {
+		s.logger.Error("error during exporting "+step, slog.String("error", err.Error()))
+		return model.AccountExport{}, errors.New("failed to export personal data")
+	}
+
+	profile, err := s.userRepository.FindProfile(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return export, service.ErrUserNotFound
+	}
+	if err != nil {
+		return failed("profile", err)
+	}
+	export.Profile = profile
+
+	if export.Privacy, err = s.settingsRepository.FindPrivacy(ctx, userId); err != nil {
+		return failed("privacy settings", err)
+	}
+	vacation, err := s.settingsRepository.FindVacation(ctx, userId)
+	if err != nil && !errors.Is(err, repository.ErrVacationNotFound) {
+		return failed("vacation", err)
+	}
+	if err == nil {
+		export.Vacation = &vacation
+	}
+	if export.Channels, err = s.channelRepository.FindUserChannels(ctx, userId); err != nil {
+		return failed("channels", err)
+	}
+	export.Subscriptions, err = s.userRepository.FindUsersSubscribedTo(ctx, userId, model.UserSortName)
+	if err != nil {
+		return failed("subscriptions", err)
+	}
+	if export.SubscriptionRules, err = s.subscriptionRepository.FindRules(ctx, userId); err != nil {
+		return failed("subscription rules", err)
+	}
+	if export.Subscribers, err = s.userRepository.FindSubscribers(ctx, userId); err != nil {
+		return failed("subscribers", err)
+	}
+	if export.Notifications, err = s.inboxRepository.FindAllNotifications(ctx, userId); err != nil {
+		return failed("notifications", err)
+	}
+	if export.ReminderStates, err = s.reminderRepository.FindStates(ctx, userId); err != nil {
+		return failed("reminder states", err)
+	}
+	if export.Webhooks, err = s.webhookRepository.FindWebhooks(ctx, userId); err != nil {
+		return failed("webhooks", err)
+	}
+	if export.WebhookDeliveries, err = s.webhookRepository.FindAllDeliveries(ctx, userId); err != nil {
+		return failed("webhook deliveries", err)
+	}
+	if export.ChatWebhooks, err = s.chatWebhookRepository.FindWebhooks(ctx, userId); err != nil {
+		return failed("chat webhooks", err)
+	}
+	if export.PushSubscriptions, err = s.pushRepository.FindSubscriptions(ctx, userId); err != nil {
+		return failed("push subscriptions", err)
+	}
+	return export, nil
+}
+
+func (s *accountService) DeleteScheduled(ctx context.Context) error {
+	// removal is idempotent, so several workers may run it at once without a lock
+	count, err := s.userRepository.DeleteUsersScheduledBefore(ctx, time.Now().Add(-s.gracePeriod))
+	if err != nil {
+		s.logger.Error("failed to delete accounts scheduled for deletion", slog.String("error", err.Error()))
+		return err
+	}
+	s.logger.Info("accounts scheduled for deletion deleted", slog.Int64("count", count))
+	return nil
+}
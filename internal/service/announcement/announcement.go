@@ -0,0 +1,118 @@
+package announcement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/pkg/chatwebhook"
+)
+
+type announcementService struct {
+	chatWebhookRepository repository.ChatWebhookRepository
+	client                chatwebhook.Client
+	logger                *slog.Logger
+}
+
+func NewAnnouncementService(
+	chatWebhookRepository repository.ChatWebhookRepository,
+	client chatwebhook.Client,
+	logger *slog.Logger,
+) *announcementService {
+	return &announcementService{
+		chatWebhookRepository: chatWebhookRepository,
+		client:                client,
+		logger:                logger,
+	}
+}
+
+func (s *announcementService) Announce(ctx context.Context) error {
+	tx, err := s.chatWebhookRepository.GetLock(ctx)
+	if errors.Is(err, repository.ErrLockTaken) {
+		s.logger.Info("announcements are already done by other worker")
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("failed to take lock", slog.String("error", err.Error()))
+		return err
+	}
+	defer func() {
+		err := tx.Commit()
+		if err != nil {
+			s.logger.Error("error committing transaction", slog.String("error", err.Error()))
+		}
+	}()
+
+	announcements, err := s.chatWebhookRepository.FindAnnouncements(ctx, tx)
+	if err != nil {
+		s.logger.Error("failed to retrieve announcements", slog.String("error", err.Error()))
+		return err
+	}
+
+	// every webhook receives a single message with all of its birthdays
+	webhooksOrder := make([]string, 0)
+	webhooksAnnouncements := make(map[string][]model.Announcement)
+	for _, v := range announcements {
+		if _, ok := webhooksAnnouncements[v.WebhookId]; !ok {
+			webhooksOrder = append(webhooksOrder, v.WebhookId)
+		}
+		webhooksAnnouncements[v.WebhookId] = append(webhooksAnnouncements[v.WebhookId], v)
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, webhookId := range webhooksOrder {
+		wg.Add(1)
+		go func(announcements []model.Announcement) {
+			defer wg.Done()
+			webhook := announcements[0]
+			if err := s.client.Post(ctx, webhook.WebhookUrl, formatText(announcements)); err != nil {
+				s.logger.Error(
+					"failed to post announcement",
+					slog.String("webhook_id", webhook.WebhookId),
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+			s.logger.Info("successfully posted announcement", slog.String("webhook_id", webhook.WebhookId))
+		}(webhooksAnnouncements[webhookId])
+	}
+	wg.Wait()
+	return nil
+}
+
+func mention(announcement model.Announcement) string {
+	name := fmt.Sprintf("%s %s", announcement.UserName, announcement.UserSurname)
+	if announcement.UserChatHandle == nil || *announcement.UserChatHandle == "" {
+		return name
+	}
+	handle := strings.TrimPrefix(*announcement.UserChatHandle, "@")
+	// slack mentions require member id, mattermost ones require username
+	if announcement.WebhookFormat == model.ChatWebhookFormatSlack {
+		return fmt.Sprintf("%s (<@%s>)", name, handle)
+	}
+	return fmt.Sprintf("%s (@%s)", name, handle)
+}
+
+func formatText(announcements []model.Announcement) string {
+	lines := make([]string, 0, len(announcements))
+	for _, v := range announcements {
+		if v.DaysUntilBirthday == 0 {
+			lines = append(lines, fmt.Sprintf(":tada: Happy birthday, %s!", mention(v)))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(
+			":calendar: %s will celebrate birthday in %d days, on %d of %s",
+			mention(v),
+			v.DaysUntilBirthday,
+			v.BirthdayDate.Day(),
+			time.Month(v.BirthdayDate.Month()).String(),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
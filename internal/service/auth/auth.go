@@ -2,31 +2,77 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
 	"github.com/vshevchenk0/bday-notifier/pkg/jwt"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
+	"github.com/vshevchenk0/bday-notifier/pkg/mailer"
 	"golang.org/x/crypto/bcrypt"
 )
 
+type AuthServiceConfig struct {
+	// PublicUrl is the base url of the api used in email verification links,
+	// emails are not verified when it is empty
+	PublicUrl string
+	// VerificationResendInterval is the minimal time between verification emails sent to the user
+	VerificationResendInterval time.Duration
+	// PasswordResetTokenTtl is the time password reset token is valid for
+	PasswordResetTokenTtl time.Duration
+	// RefreshTokenTtl is the time refresh token is valid for, it should exceed the access token ttl
+	RefreshTokenTtl time.Duration
+}
+
 type authService struct {
-	userRepository repository.UserRepository
-	tokenManager   jwt.Manager
-	logger         *slog.Logger
+	userRepository             repository.UserRepository
+	passwordResetRepository    repository.PasswordResetRepository
+	refreshTokenRepository     repository.RefreshTokenRepository
+	tokenManager               jwt.Manager
+	linkManager                linktoken.Manager
+	mailer                     mailer.Mailer
+	webhookService             service.WebhookService
+	publicUrl                  string
+	verificationResendInterval time.Duration
+	passwordResetTokenTtl      time.Duration
+	refreshTokenTtl            time.Duration
+	logger                     *slog.Logger
 }
 
 func NewAuthService(
 	userRepository repository.UserRepository,
+	passwordResetRepository repository.PasswordResetRepository,
+	refreshTokenRepository repository.RefreshTokenRepository,
 	tokenManager jwt.Manager,
+	linkManager linktoken.Manager,
+	mailer mailer.Mailer,
+	webhookService service.WebhookService,
+	config *AuthServiceConfig,
 	logger *slog.Logger,
 ) *authService {
 	return &authService{
-		userRepository: userRepository,
-		tokenManager:   tokenManager,
-		logger:         logger,
+		userRepository:             userRepository,
+		passwordResetRepository:    passwordResetRepository,
+		refreshTokenRepository:     refreshTokenRepository,
+		tokenManager:               tokenManager,
+		linkManager:                linkManager,
+		mailer:                     mailer,
+		webhookService:             webhookService,
+		publicUrl:                  strings.TrimRight(config.PublicUrl, "/"),
+		verificationResendInterval: config.VerificationResendInterval,
+		passwordResetTokenTtl:      config.PasswordResetTokenTtl,
+		refreshTokenTtl:            config.RefreshTokenTtl,
+		logger:                     logger,
 	}
 }
 
@@ -40,7 +86,11 @@ func (s *authService) SignUp(
 		return emptyResponse, errors.New("error during sign up")
 	}
 
-	userId, err := s.userRepository.CreateUser(ctx, email, name, surname, string(passwordHash), birthdayDate)
+	// without public url verification link cannot be built, so emails are trusted as they are
+	emailVerified := s.publicUrl == ""
+	userId, err := s.userRepository.CreateUser(
+		ctx, email, name, surname, string(passwordHash), birthdayDate, emailVerified,
+	)
 	if errors.Is(err, repository.ErrEmailIsNotUnique) {
 		return emptyResponse, service.ErrDuplicateUser
 	}
@@ -49,13 +99,28 @@ func (s *authService) SignUp(
 		return emptyResponse, errors.New("error during sign up")
 	}
 
-	token, err := s.tokenManager.NewToken(userId)
+	// new user has no privacy settings yet, so the birthday is published as is
+	s.webhookService.PublishToSubscribers(ctx, model.EventUserCreated, model.User{
+		Id:           userId,
+		Name:         name,
+		Surname:      surname,
+		BirthdayDate: birthdayDate.Format(time.DateOnly),
+	}, userId)
+
+	// user is signed up anyway, verification email can be resent later
+	if !emailVerified {
+		if err := s.sendVerification(ctx, userId, email, name); err != nil {
+			s.logger.Error("error during sending verification email", slog.String("error", err.Error()))
+		}
+	}
+
+	token, err := s.issueTokens(ctx, userId)
 	if err != nil {
 		s.logger.Error("error during token creation", slog.String("error", err.Error()))
 		return emptyResponse, errors.New("signed up succefully, but failed to automatically authorize. please sign in")
 	}
 
-	return service.Token{AccessToken: token}, nil
+	return token, nil
 }
 
 func (s *authService) SignIn(ctx context.Context, email, password string) (service.Token, error) {
@@ -74,16 +139,291 @@ func (s *authService) SignIn(ctx context.Context, email, password string) (servi
 		return emptyResponse, service.ErrInvalidPassword
 	}
 
-	token, err := s.tokenManager.NewToken(user.Id)
+	token, err := s.issueTokens(ctx, user.Id)
 	if err != nil {
 		s.logger.Error("error during token creation", slog.String("error", err.Error()))
 		return emptyResponse, errors.New("failed to authorize")
 	}
 
-	return service.Token{AccessToken: token}, nil
+	return token, nil
+}
+
+// issueTokens starts a new session of the user
+func (s *authService) issueTokens(ctx context.Context, userId string) (service.Token, error) {
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return service.Token{}, err
+	}
+	expiresAt := time.Now().Add(s.refreshTokenTtl)
+	tokenId, err := s.refreshTokenRepository.CreateToken(ctx, userId, hashToken(refreshToken), expiresAt)
+	if err != nil {
+		return service.Token{}, err
+	}
+	accessToken, err := s.tokenManager.NewToken(userId, tokenId)
+	if err != nil {
+		return service.Token{}, err
+	}
+	return service.Token{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// VerifyToken returns user id and token id of the access token.
+// access token shares id with the refresh token issued along with it,
+// so it stays valid only until the session is revoked
+func (s *authService) VerifyToken(ctx context.Context, tokenString string) (string, string, error) {
+	claims, err := s.tokenManager.ParseToken(tokenString)
+	if err != nil {
+		return "", "", err
+	}
+	// tokens issued before sessions were introduced cannot be revoked, so they are not accepted
+	if claims.TokenId == "" {
+		return "", "", service.ErrTokenRevoked
+	}
+	token, err := s.refreshTokenRepository.FindToken(ctx, claims.TokenId)
+	if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+		return "", "", service.ErrTokenRevoked
+	}
+	if err != nil {
+		s.logger.Error("error during finding refresh token", slog.String("error", err.Error()))
+		return "", "", errors.New("failed to verify token")
+	}
+	if token.RevokedAt != nil || token.UserId != claims.UserId {
+		return "", "", service.ErrTokenRevoked
+	}
+	return claims.UserId, claims.TokenId, nil
+}
+
+// Refresh rotates refresh token, so every refresh token can be used only once
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (service.Token, error) {
+	emptyResponse := service.Token{}
+	newRefreshToken, err := newRandomToken()
+	if err != nil {
+		s.logger.Error("error during generating refresh token", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to refresh token")
+	}
+	expiresAt := time.Now().Add(s.refreshTokenTtl)
+	token, err := s.refreshTokenRepository.RotateToken(
+		ctx, hashToken(refreshToken), hashToken(newRefreshToken), expiresAt,
+	)
+	if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+		return emptyResponse, service.ErrInvalidRefreshToken
+	}
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		s.logger.Warn(
+			"revoked session due to refresh token reuse",
+			slog.String("user_id", token.UserId), slog.String("session_id", token.SessionId),
+		)
+		return emptyResponse, service.ErrRefreshTokenReused
+	}
+	if err != nil {
+		s.logger.Error("error during rotating refresh token", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to refresh token")
+	}
+
+	accessToken, err := s.tokenManager.NewToken(token.UserId, token.Id)
+	if err != nil {
+		s.logger.Error("error during token creation", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to refresh token")
+	}
+	return service.Token{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+}
+
+// Logout revokes the session of the access token, along with every token issued in it
+func (s *authService) Logout(ctx context.Context, tokenId string) error {
+	err := s.refreshTokenRepository.RevokeSession(ctx, tokenId)
+	if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+		return service.ErrTokenRevoked
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during revoking session", slog.String("error", err.Error()))
+		return errors.New("failed to log out")
+	}
+	return nil
+}
+
+// ForgotPassword sends reset token only to verified emails, since unverified one may belong to someone else.
+// it does not report whether the email was found, so that registered emails cannot be enumerated
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	user, _ := s.userRepository.FindByEmail(ctx, email)
+	if user.Email == "" || user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		s.logger.Error("error during generating password reset token", slog.String("error", err.Error()))
+		return errors.New("failed to send password reset token")
+	}
+	expiresAt := time.Now().Add(s.passwordResetTokenTtl)
+	err = s.passwordResetRepository.CreateToken(ctx, user.Id, hashToken(token), expiresAt)
+	if err != nil {
+		s.logger.Error("error during saving password reset token", slog.String("error", err.Error()))
+		return errors.New("failed to send password reset token")
+	}
+
+	body := fmt.Sprintf(
+		"Hi, %s!\n\nUse this token to reset your password, it is valid for %s:\n%s\n\n"+
+			"If you did not request a password reset, just ignore this email.",
+		user.Name, s.passwordResetTokenTtl, token,
+	)
+	if err := s.mailer.Send(ctx, []string{user.Email}, "Password reset", body); err != nil {
+		s.logger.Error("error during sending password reset token", slog.String("error", err.Error()))
+		return errors.New("failed to send password reset token")
+	}
+	return nil
+}
+
+func newRandomToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// hashToken returns the form the token is stored in, so that tokens cannot be used if the db leaks
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
 
-func (s *authService) VerifyToken(ctx context.Context, tokenString string) (string, error) {
-	userId, err := s.tokenManager.ParseToken(tokenString)
-	return userId, err
+func (s *authService) ResetPassword(ctx context.Context, token, password string) error {
+	userId, err := s.passwordResetRepository.ConsumeToken(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrResetTokenNotFound) {
+		return service.ErrInvalidResetToken
+	}
+	if err != nil {
+		s.logger.Error("error during consuming password reset token", slog.String("error", err.Error()))
+		return errors.New("failed to reset password")
+	}
+	return s.updatePassword(ctx, userId, password)
+}
+
+// ChangePassword returns new tokens, since every session of the user is revoked
+func (s *authService) ChangePassword(
+	ctx context.Context, userId, oldPassword, newPassword string,
+) (service.Token, error) {
+	emptyResponse := service.Token{}
+	passwordHash, err := s.userRepository.FindPasswordHash(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return emptyResponse, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during finding password hash", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to change password")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(oldPassword)); err != nil {
+		return emptyResponse, service.ErrInvalidPassword
+	}
+
+	if err := s.updatePassword(ctx, userId, newPassword); err != nil {
+		return emptyResponse, err
+	}
+
+	token, err := s.issueTokens(ctx, userId)
+	if err != nil {
+		s.logger.Error("error during token creation", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("password changed succefully, but failed to authorize. please sign in")
+	}
+	return token, nil
+}
+
+func (s *authService) updatePassword(ctx context.Context, userId, password string) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.Error("error during password hashing", slog.String("error", err.Error()))
+		return errors.New("failed to update password")
+	}
+	err = s.userRepository.UpdatePassword(ctx, userId, string(passwordHash))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrUserNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during updating password", slog.String("error", err.Error()))
+		return errors.New("failed to update password")
+	}
+	return nil
+}
+
+func (s *authService) sendVerification(ctx context.Context, userId, email, name string) error {
+	token, err := s.linkManager.NewToken(model.LinkActionVerifyEmail, map[string]string{
+		"user_id": userId,
+		"email":   email,
+	})
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/auth/email/verify?token=%s", s.publicUrl, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Hi, %s!\n\nPlease verify your email to start receiving birthday reminders:\n%s\n\n"+
+			"If you did not sign up, just ignore this email.",
+		name, link,
+	)
+	return s.mailer.Send(ctx, []string{email}, "Verify your email", body)
+}
+
+func (s *authService) ResendVerification(ctx context.Context, userId string) error {
+	if s.publicUrl == "" {
+		return service.ErrEmailAlreadyVerified
+	}
+	user, err := s.userRepository.ReserveVerification(ctx, userId, time.Now().Add(-s.verificationResendInterval))
+	if errors.Is(err, repository.ErrVerificationNotAllowed) {
+		profile, err := s.userRepository.FindProfile(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return service.ErrUserNotFound
+		}
+		if err == nil && profile.EmailVerifiedAt != nil {
+			return service.ErrEmailAlreadyVerified
+		}
+		return service.ErrVerificationTooFrequent
+	}
+	if err != nil {
+		s.logger.Error("error during reserving verification email", slog.String("error", err.Error()))
+		return errors.New("failed to send verification email")
+	}
+
+	if err := s.sendVerification(ctx, user.Id, user.Email, user.Name); err != nil {
+		s.logger.Error("error during sending verification email", slog.String("error", err.Error()))
+		return errors.New("failed to send verification email")
+	}
+	return nil
+}
+
+func (s *authService) parseVerificationToken(token string) (string, string, error) {
+	params, err := s.linkManager.ParseToken(model.LinkActionVerifyEmail, token)
+	if err != nil || params["user_id"] == "" || params["email"] == "" {
+		return "", "", service.ErrInvalidLinkToken
+	}
+	return params["user_id"], params["email"], nil
+}
+
+func (s *authService) CheckVerificationToken(ctx context.Context, token string) error {
+	_, _, err := s.parseVerificationToken(token)
+	return err
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	userId, email, err := s.parseVerificationToken(token)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepository.VerifyEmail(ctx, userId, email)
+	// email was changed after the link had been sent, so the link is stale
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrInvalidLinkToken
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during verifying email", slog.String("error", err.Error()))
+		return errors.New("failed to verify email")
+	}
+	return nil
 }
@@ -0,0 +1,61 @@
+package birthday
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+)
+
+type birthdayService struct {
+	birthdayRepository repository.BirthdayRepository
+	logger             *slog.Logger
+}
+
+func NewBirthdayService(birthdayRepository repository.BirthdayRepository, logger *slog.Logger) *birthdayService {
+	return &birthdayService{
+		birthdayRepository: birthdayRepository,
+		logger:             logger,
+	}
+}
+
+func (s *birthdayService) FindUpcomingBirthdays(
+	ctx context.Context, userId, scope string, days int,
+) ([]model.UserBirthday, error) {
+	birthdays, err := s.birthdayRepository.FindUpcomingBirthdays(ctx, userId, scope, days)
+	if err != nil {
+		s.logger.Error("error during finding upcoming birthdays", slog.String("error", err.Error()))
+		return nil, errors.New("failed to find upcoming birthdays")
+	}
+	return birthdays, nil
+}
+
+// FindCalendar returns every day of the month with birthdays falling on it, days without birthdays included
+func (s *birthdayService) FindCalendar(
+	ctx context.Context, userId, scope string, year, month int,
+) ([]model.CalendarDay, error) {
+	birthdays, err := s.birthdayRepository.FindMonthBirthdays(ctx, userId, scope, year, month)
+	if err != nil {
+		s.logger.Error("error during finding month birthdays", slog.String("error", err.Error()))
+		return nil, errors.New("failed to find month birthdays")
+	}
+
+	byDate := make(map[string][]model.UserBirthday)
+	for _, birthday := range birthdays {
+		byDate[birthday.Date] = append(byDate[birthday.Date], birthday)
+	}
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	days := make([]model.CalendarDay, 0, 31)
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		dayBirthdays := byDate[date]
+		if dayBirthdays == nil {
+			dayBirthdays = []model.UserBirthday{}
+		}
+		days = append(days, model.CalendarDay{Date: date, Birthdays: dayBirthdays})
+	}
+	return days, nil
+}
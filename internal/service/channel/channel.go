@@ -0,0 +1,139 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+type channelService struct {
+	channelRepository repository.ChannelRepository
+	pushRepository    repository.PushRepository
+	userRepository    repository.UserRepository
+	logger            *slog.Logger
+}
+
+func NewChannelService(
+	channelRepository repository.ChannelRepository,
+	pushRepository repository.PushRepository,
+	userRepository repository.UserRepository,
+	logger *slog.Logger,
+) *channelService {
+	return &channelService{
+		channelRepository: channelRepository,
+		pushRepository:    pushRepository,
+		userRepository:    userRepository,
+		logger:            logger,
+	}
+}
+
+func (s *channelService) FindUserChannels(ctx context.Context, userId string) ([]model.UserChannel, error) {
+	channels, err := s.channelRepository.FindUserChannels(ctx, userId)
+	if err != nil {
+		return nil, errors.New("failed to find channels")
+	}
+	return channels, nil
+}
+
+// checkEmailAddress makes sure that email reminders go only to the signup address, which is the only one verified
+func (s *channelService) checkEmailAddress(ctx context.Context, userId, address string) error {
+	user, err := s.userRepository.FindProfile(ctx, userId)
+	if err != nil {
+		s.logger.Error("error during finding user", slog.String("error", err.Error()))
+		return errors.New("failed to check email address")
+	}
+	if !strings.EqualFold(user.Email, address) {
+		return service.ErrUnverifiedEmailAddress
+	}
+	return nil
+}
+
+func (s *channelService) SaveUserChannel(ctx context.Context, userId, channel, address string, enabled bool) error {
+	if channel == model.ChannelEmail {
+		if err := s.checkEmailAddress(ctx, userId, address); err != nil {
+			return err
+		}
+	}
+
+	err := s.channelRepository.SaveUserChannel(ctx, userId, channel, address, enabled)
+	if err != nil {
+		s.logger.Error("error during saving channel", slog.String("error", err.Error()))
+		return errors.New("failed to save channel")
+	}
+	return nil
+}
+
+func (s *channelService) DeleteUserChannel(ctx context.Context, userId, channel string) error {
+	err := s.channelRepository.DeleteUserChannel(ctx, userId, channel)
+	if errors.Is(err, repository.ErrChannelNotFound) {
+		return service.ErrChannelNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete channel")
+	}
+	return nil
+}
+
+func (s *channelService) FindSubscriptionChannels(
+	ctx context.Context, userId, subscriberId string,
+) ([]model.SubscriptionChannel, error) {
+	channels, err := s.channelRepository.FindSubscriptionChannels(ctx, userId, subscriberId)
+	if err != nil {
+		return nil, errors.New("failed to find subscription channels")
+	}
+	return channels, nil
+}
+
+func (s *channelService) SaveSubscriptionChannel(
+	ctx context.Context, userId, subscriberId, channel string, address *string, enabled bool,
+) error {
+	// webpush address is the id of a browser subscription, so it may only point to own subscriptions
+	if channel == model.ChannelWebPush && address != nil {
+		_, err := s.pushRepository.FindSubscription(ctx, *address, subscriberId)
+		if errors.Is(err, repository.ErrPushSubscriptionNotFound) {
+			return service.ErrPushSubscriptionNotFound
+		}
+		if err != nil {
+			s.logger.Error("error during finding push subscription", slog.String("error", err.Error()))
+			return errors.New("failed to save subscription channel")
+		}
+	}
+
+	if channel == model.ChannelEmail && address != nil {
+		if err := s.checkEmailAddress(ctx, subscriberId, *address); err != nil {
+			return err
+		}
+	}
+
+	err := s.channelRepository.SaveSubscriptionChannel(ctx, userId, subscriberId, channel, address, enabled)
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return service.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during saving subscription channel", slog.String("error", err.Error()))
+		return errors.New("failed to save subscription channel")
+	}
+	return nil
+}
+
+func (s *channelService) DeleteSubscriptionChannel(ctx context.Context, userId, subscriberId, channel string) error {
+	err := s.channelRepository.DeleteSubscriptionChannel(ctx, userId, subscriberId, channel)
+	if errors.Is(err, repository.ErrChannelNotFound) {
+		return service.ErrChannelNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete subscription channel")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+const (
+	testUserId       = "0b7a4c55-3f0c-4a6e-9a57-0f1c6f1ee8a1"
+	testBirthdayUser = "458c370e-12f9-4e8c-9c4b-ca0a123a6151"
+	testEmail        = "user@example.com"
+)
+
+type fakeUserRepository struct {
+	repository.UserRepository
+	profile model.User
+}
+
+func (r *fakeUserRepository) FindProfile(ctx context.Context, userId string) (model.User, error) {
+	if userId != r.profile.Id {
+		return model.User{}, repository.ErrUserNotFound
+	}
+	return r.profile, nil
+}
+
+type savedChannel struct {
+	channel string
+	address string
+}
+
+type fakeChannelRepository struct {
+	repository.ChannelRepository
+	saved []savedChannel
+}
+
+func (r *fakeChannelRepository) SaveUserChannel(
+	ctx context.Context, userId, channel, address string, enabled bool,
+) error {
+	r.saved = append(r.saved, savedChannel{channel: channel, address: address})
+	return nil
+}
+
+func (r *fakeChannelRepository) SaveSubscriptionChannel(
+	ctx context.Context, userId, subscriberId, channel string, address *string, enabled bool,
+) error {
+	r.saved = append(r.saved, savedChannel{channel: channel, address: *address})
+	return nil
+}
+
+func newTestService() (*channelService, *fakeChannelRepository) {
+	channelRepository := &fakeChannelRepository{}
+	userRepository := &fakeUserRepository{profile: model.User{Id: testUserId, Email: testEmail}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewChannelService(channelRepository, nil, userRepository, logger), channelRepository
+}
+
+func TestSaveUserChannelOwnEmail(t *testing.T) {
+	s, channelRepository := newTestService()
+
+	if err := s.SaveUserChannel(context.Background(), testUserId, model.ChannelEmail, testEmail, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(channelRepository.saved) != 1 || channelRepository.saved[0].address != testEmail {
+		t.Errorf("saved = %+v, want the email channel with the account email", channelRepository.saved)
+	}
+}
+
+func TestSaveUserChannelForeignEmail(t *testing.T) {
+	s, channelRepository := newTestService()
+
+	err := s.SaveUserChannel(context.Background(), testUserId, model.ChannelEmail, "other@example.com", true)
+	if !errors.Is(err, service.ErrUnverifiedEmailAddress) {
+		t.Errorf("error = %v, want ErrUnverifiedEmailAddress", err)
+	}
+	if len(channelRepository.saved) != 0 {
+		t.Errorf("saved = %+v, want nothing saved", channelRepository.saved)
+	}
+}
+
+func TestSaveSubscriptionChannelOwnEmail(t *testing.T) {
+	s, channelRepository := newTestService()
+
+	address := "User@Example.com"
+	err := s.SaveSubscriptionChannel(
+		context.Background(), testBirthdayUser, testUserId, model.ChannelEmail, &address, true,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(channelRepository.saved) != 1 {
+		t.Errorf("saved = %+v, want the email override", channelRepository.saved)
+	}
+}
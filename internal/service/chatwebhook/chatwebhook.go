@@ -0,0 +1,68 @@
+package chatwebhook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/chatwebhook"
+)
+
+type chatWebhookService struct {
+	chatWebhookRepository repository.ChatWebhookRepository
+	chatWebhookClient     chatwebhook.Client
+	logger                *slog.Logger
+}
+
+func NewChatWebhookService(
+	chatWebhookRepository repository.ChatWebhookRepository,
+	chatWebhookClient chatwebhook.Client,
+	logger *slog.Logger,
+) *chatWebhookService {
+	return &chatWebhookService{
+		chatWebhookRepository: chatWebhookRepository,
+		chatWebhookClient:     chatWebhookClient,
+		logger:                logger,
+	}
+}
+
+func (s *chatWebhookService) CreateWebhook(ctx context.Context, webhook model.ChatWebhook) (model.ChatWebhook, error) {
+	// the url is a secret, so it is not logged
+	if err := s.chatWebhookClient.ValidateUrl(ctx, webhook.Url); err != nil {
+		s.logger.Info("rejected chat webhook url", slog.String("error", err.Error()))
+		return model.ChatWebhook{}, service.ErrInvalidChatWebhookUrl
+	}
+
+	id, err := s.chatWebhookRepository.CreateWebhook(ctx, webhook)
+	if err != nil {
+		s.logger.Error("error during saving chat webhook", slog.String("error", err.Error()))
+		return model.ChatWebhook{}, errors.New("failed to create chat webhook")
+	}
+	webhook.Id = id
+	return webhook, nil
+}
+
+func (s *chatWebhookService) FindWebhooks(ctx context.Context, ownerId string) ([]model.ChatWebhook, error) {
+	webhooks, err := s.chatWebhookRepository.FindWebhooks(ctx, ownerId)
+	if err != nil {
+		return nil, errors.New("failed to find chat webhooks")
+	}
+	return webhooks, nil
+}
+
+func (s *chatWebhookService) DeleteWebhook(ctx context.Context, id, ownerId string) error {
+	err := s.chatWebhookRepository.DeleteWebhook(ctx, id, ownerId)
+	if errors.Is(err, repository.ErrChatWebhookNotFound) {
+		return service.ErrChatWebhookNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete chat webhook")
+	}
+	return nil
+}
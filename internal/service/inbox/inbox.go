@@ -0,0 +1,105 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+type inboxService struct {
+	inboxRepository repository.InboxRepository
+	retention       time.Duration
+	logger          *slog.Logger
+}
+
+func NewInboxService(
+	inboxRepository repository.InboxRepository,
+	retention time.Duration,
+	logger *slog.Logger,
+) *inboxService {
+	return &inboxService{
+		inboxRepository: inboxRepository,
+		retention:       retention,
+		logger:          logger,
+	}
+}
+
+func (s *inboxService) FindNotifications(
+	ctx context.Context, userId, cursor string, unreadOnly bool, limit int,
+) ([]model.InboxNotification, string, error) {
+	// one extra row is requested to find out whether the next page exists
+	notifications, err := s.inboxRepository.FindNotifications(ctx, userId, cursor, unreadOnly, limit+1)
+	if errors.Is(err, repository.ErrInboxCursorNotFound) {
+		return nil, "", service.ErrInvalidInboxCursor
+	}
+	if err != nil {
+		s.logger.Error("error during finding inbox notifications", slog.String("error", err.Error()))
+		return nil, "", errors.New("failed to find notifications")
+	}
+	nextCursor := ""
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		nextCursor = notifications[limit-1].Id
+	}
+	return notifications, nextCursor, nil
+}
+
+func (s *inboxService) CountUnread(ctx context.Context, userId string) (int, error) {
+	count, err := s.inboxRepository.CountUnread(ctx, userId)
+	if err != nil {
+		return 0, errors.New("failed to count unread notifications")
+	}
+	return count, nil
+}
+
+func (s *inboxService) MarkRead(ctx context.Context, userId, id string) error {
+	count, err := s.inboxRepository.MarkRead(ctx, userId, []string{id})
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to mark notification as read")
+	}
+	if count == 0 {
+		return service.ErrInboxNotificationNotFound
+	}
+	return nil
+}
+
+func (s *inboxService) MarkManyRead(ctx context.Context, userId string, ids []string) (int64, error) {
+	count, err := s.inboxRepository.MarkRead(ctx, userId, ids)
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return 0, service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return 0, errors.New("failed to mark notifications as read")
+	}
+	return count, nil
+}
+
+func (s *inboxService) MarkAllRead(ctx context.Context, userId string) (int64, error) {
+	count, err := s.inboxRepository.MarkAllRead(ctx, userId)
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return 0, service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return 0, errors.New("failed to mark notifications as read")
+	}
+	return count, nil
+}
+
+func (s *inboxService) DeleteExpired(ctx context.Context) error {
+	// removal is idempotent, so several workers may run it at once without a lock
+	count, err := s.inboxRepository.DeleteNotificationsBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		s.logger.Error("failed to delete expired inbox notifications", slog.String("error", err.Error()))
+		return err
+	}
+	s.logger.Info("expired inbox notifications deleted", slog.Int64("count", count))
+	return nil
+}
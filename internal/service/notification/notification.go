@@ -5,41 +5,65 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
-	"github.com/vshevchenk0/bday-notifier/pkg/mailer"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
 )
 
+var errChannelNotRegistered = errors.New("channel is not registered")
+
+type NotificationServiceConfig struct {
+	// PublicUrl is the base url of the api used in links sent to users,
+	// no links are sent when it is empty
+	PublicUrl string
+	// Concurrency is the maximum number of notifications sent at the same time
+	Concurrency int
+}
+
 type notificationService struct {
 	notificationRepository repository.NotificationRepository
-	mailer                 mailer.Mailer
+	inboxRepository        repository.InboxRepository
+	channels               map[string]channel.Channel
+	linkManager            linktoken.Manager
+	publicUrl              string
+	concurrency            int
 	logger                 *slog.Logger
 }
 
 func NewNotificationService(
 	notificationRepository repository.NotificationRepository,
-	mailer mailer.Mailer,
+	inboxRepository repository.InboxRepository,
+	channels []channel.Channel,
+	linkManager linktoken.Manager,
+	config *NotificationServiceConfig,
 	logger *slog.Logger,
 ) *notificationService {
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	channelsMap := make(map[string]channel.Channel, len(channels))
+	for _, c := range channels {
+		channelsMap[c.Name()] = c
+	}
 	return &notificationService{
 		notificationRepository: notificationRepository,
-		mailer:                 mailer,
+		inboxRepository:        inboxRepository,
+		channels:               channelsMap,
+		linkManager:            linkManager,
+		publicUrl:              strings.TrimRight(config.PublicUrl, "/"),
+		concurrency:            concurrency,
 		logger:                 logger,
 	}
 }
 
 func (s *notificationService) NotifyUsers(ctx context.Context) error {
-	type UserId string
-	type UserInfo struct {
-		name              string
-		surname           string
-		birthdayDate      time.Time
-		daysUntilBirthday int
-		subscribersEmails []string
-	}
-
 	tx, err := s.notificationRepository.GetLock(ctx)
 	defer func() {
 		err := tx.Commit()
@@ -63,38 +87,152 @@ func (s *notificationService) NotifyUsers(ctx context.Context) error {
 		_ = tx.Rollback()
 		return err
 	}
-
-	notificationsMap := make(map[UserId]UserInfo)
-	for _, v := range notificationRecords {
-		userInfo, ok := notificationsMap[UserId(v.BirthdayUserId)]
-		if !ok {
-			notificationsMap[UserId(v.BirthdayUserId)] = UserInfo{
-				name:              v.BirthdayUserName,
-				surname:           v.BirthdayUserSurname,
-				birthdayDate:      v.BirthdayDate,
-				daysUntilBirthday: v.DaysUntilBirthday,
-				subscribersEmails: []string{v.SubscriberEmail},
-			}
-		} else {
-			userInfo.subscribersEmails = append(userInfo.subscribersEmails, v.SubscriberEmail)
-			notificationsMap[UserId(v.BirthdayUserId)] = userInfo
-		}
+	// inbox entries do not depend on channels, so subscribers with every channel disabled still get them
+	reminders, err := s.notificationRepository.FindDueReminders(ctx, tx)
+	if err != nil {
+		s.logger.Error("failed to retrieve due reminders", slog.String("error", err.Error()))
+		_ = tx.Rollback()
+		return err
 	}
 
+	mu := &sync.Mutex{}
+	deliveries := make([]model.Delivery, 0, len(notificationRecords))
 	wg := &sync.WaitGroup{}
-	for _, userInfo := range notificationsMap {
+	semaphore := make(chan struct{}, s.concurrency)
+	for _, notification := range notificationRecords {
 		wg.Add(1)
-		subject := fmt.Sprintf("Birthday of %s %s", userInfo.name, userInfo.surname)
-		body := fmt.Sprintf(
-			"%s %s will celebrate birthday in %d days, on %d of %s!",
-			userInfo.name,
-			userInfo.surname,
-			userInfo.daysUntilBirthday,
-			userInfo.birthdayDate.Day(),
-			time.Month(userInfo.birthdayDate.Month()).String(),
-		)
-		go s.mailer.Send(ctx, wg, userInfo.subscribersEmails, subject, body)
+		semaphore <- struct{}{}
+		go func(notification model.Notification) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			delivery := s.deliver(ctx, notification)
+			mu.Lock()
+			deliveries = append(deliveries, delivery)
+			mu.Unlock()
+		}(notification)
 	}
 	wg.Wait()
+
+	// deliveries are saved outside of the lock transaction, which is read only
+	if err := s.notificationRepository.SaveDeliveries(ctx, deliveries); err != nil {
+		s.logger.Error("failed to save delivery results", slog.String("error", err.Error()))
+	}
+	if err := s.inboxRepository.CreateNotifications(ctx, newInboxNotifications(reminders)); err != nil {
+		s.logger.Error("failed to save inbox notifications", slog.String("error", err.Error()))
+	}
 	return nil
 }
+
+// newInboxNotifications keeps a single inbox entry per subscriber and birthday person,
+// since several reminders about the same birthday may be due for a subscriber on the same day
+func newInboxNotifications(notifications []model.Notification) []model.InboxNotification {
+	type key struct{ subscriberId, birthdayUserId string }
+	seen := make(map[key]struct{}, len(notifications))
+	inboxNotifications := make([]model.InboxNotification, 0, len(notifications))
+	for _, notification := range notifications {
+		k := key{subscriberId: notification.SubscriberId, birthdayUserId: notification.BirthdayUserId}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		message := newMessage(notification)
+		inboxNotifications = append(inboxNotifications, model.InboxNotification{
+			UserId:         notification.SubscriberId,
+			BirthdayUserId: notification.BirthdayUserId,
+			Subject:        message.Subject,
+			Body:           message.Body,
+		})
+	}
+	return inboxNotifications
+}
+
+func (s *notificationService) deliver(ctx context.Context, notification model.Notification) model.Delivery {
+	delivery := model.Delivery{
+		BirthdayUserId: notification.BirthdayUserId,
+		SubscriberId:   notification.SubscriberId,
+		Channel:        notification.Channel,
+		Address:        notification.Address,
+		Status:         model.DeliveryStatusSent,
+	}
+
+	c, ok := s.channels[notification.Channel]
+	if !ok {
+		s.logger.Warn("skipping notification", slog.String("channel", notification.Channel))
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.Error = errChannelNotRegistered.Error()
+		return delivery
+	}
+
+	message := newMessage(notification)
+	s.addLinks(&message)
+	if err := c.Send(ctx, message); err != nil {
+		s.logger.Error(
+			"failed to deliver notification",
+			slog.String("channel", notification.Channel),
+			slog.String("error", err.Error()),
+		)
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.Error = err.Error()
+	}
+	return delivery
+}
+
+func newMessage(notification model.Notification) channel.Message {
+	subject := fmt.Sprintf("Birthday of %s %s", notification.BirthdayUserName, notification.BirthdayUserSurname)
+	body := fmt.Sprintf(
+		"%s %s will celebrate birthday in %d days, on %d of %s!",
+		notification.BirthdayUserName,
+		notification.BirthdayUserSurname,
+		notification.DaysUntilBirthday,
+		notification.BirthdayDate.Day(),
+		time.Month(notification.BirthdayDate.Month()).String(),
+	)
+	if notification.ForwardedFromId != nil {
+		body = fmt.Sprintf(
+			"%s\n\nThis reminder was forwarded to you from %s %s, who is on vacation.",
+			body,
+			*notification.ForwardedFromName,
+			*notification.ForwardedFromSurname,
+		)
+	}
+	return channel.Message{
+		Notification: notification,
+		Subject:      subject,
+		Body:         body,
+	}
+}
+
+// addLinks adds signed links acting on the subscription the reminder was sent for
+func (s *notificationService) addLinks(message *channel.Message) {
+	notification := message.Notification
+	// links of forwarded reminders would act on the subscription of the user on vacation,
+	// and rule based reminders have no subscription for the links to act on
+	if notification.ForwardedFromId != nil || notification.SubscriptionRule != nil {
+		return
+	}
+	params := map[string]string{
+		"user_id":       notification.BirthdayUserId,
+		"subscriber_id": notification.SubscriberId,
+	}
+	message.UnsubscribeUrl = s.newLink("/api/unsubscribe", model.LinkActionUnsubscribe, params)
+	// reminder links are bound to the particular birthday, not to the whole subscription
+	params["occurrence"] = notification.BirthdayOccurrence.Format(time.DateOnly)
+	message.CongratulatedUrl = s.newLink("/api/reminders/congratulated", model.LinkActionCongratulated, params)
+	if notification.DaysUntilBirthday > 0 {
+		message.SnoozeUrl = s.newLink("/api/reminders/snooze", model.LinkActionSnooze, params)
+	}
+}
+
+// newLink returns signed link to the api path, the link is left empty when it cannot be built,
+// since notification is still worth sending without it
+func (s *notificationService) newLink(path, action string, params map[string]string) string {
+	if s.publicUrl == "" {
+		return ""
+	}
+	token, err := s.linkManager.NewToken(action, params)
+	if err != nil {
+		s.logger.Error("failed to create link token", slog.String("error", err.Error()))
+		return ""
+	}
+	return fmt.Sprintf("%s%s?token=%s", s.publicUrl, path, url.QueryEscape(token))
+}
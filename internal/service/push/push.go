@@ -0,0 +1,53 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/webpush"
+)
+
+type pushService struct {
+	pushRepository repository.PushRepository
+	logger         *slog.Logger
+}
+
+func NewPushService(pushRepository repository.PushRepository, logger *slog.Logger) *pushService {
+	return &pushService{
+		pushRepository: pushRepository,
+		logger:         logger,
+	}
+}
+
+func (s *pushService) SaveSubscription(
+	ctx context.Context, userId, endpoint, p256dh, auth string,
+) (model.PushSubscription, error) {
+	// broken keys would make every delivery fail, so reject them right away
+	if err := webpush.ValidateKeys(p256dh, auth); err != nil {
+		return model.PushSubscription{}, service.ErrInvalidPushKeys
+	}
+	subscription, err := s.pushRepository.SaveSubscription(ctx, userId, endpoint, p256dh, auth)
+	if err != nil {
+		s.logger.Error("error during saving push subscription", slog.String("error", err.Error()))
+		return model.PushSubscription{}, errors.New("failed to save push subscription")
+	}
+	return subscription, nil
+}
+
+func (s *pushService) DeleteSubscription(ctx context.Context, userId, endpoint string) error {
+	err := s.pushRepository.DeleteUserSubscription(ctx, userId, endpoint)
+	if errors.Is(err, repository.ErrPushSubscriptionNotFound) {
+		return service.ErrPushSubscriptionNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete push subscription")
+	}
+	return nil
+}
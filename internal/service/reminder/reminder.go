@@ -0,0 +1,97 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
+)
+
+type reminderLink struct {
+	userId       string
+	subscriberId string
+	occurrence   time.Time
+}
+
+type reminderService struct {
+	reminderRepository repository.ReminderRepository
+	linkManager        linktoken.Manager
+	logger             *slog.Logger
+}
+
+func NewReminderService(
+	reminderRepository repository.ReminderRepository,
+	linkManager linktoken.Manager,
+	logger *slog.Logger,
+) *reminderService {
+	return &reminderService{
+		reminderRepository: reminderRepository,
+		linkManager:        linkManager,
+		logger:             logger,
+	}
+}
+
+func (s *reminderService) parseToken(action, token string) (reminderLink, error) {
+	params, err := s.linkManager.ParseToken(action, token)
+	if err != nil || params["user_id"] == "" || params["subscriber_id"] == "" {
+		return reminderLink{}, service.ErrInvalidLinkToken
+	}
+	occurrence, err := time.Parse(time.DateOnly, params["occurrence"])
+	if err != nil {
+		return reminderLink{}, service.ErrInvalidLinkToken
+	}
+	return reminderLink{
+		userId:       params["user_id"],
+		subscriberId: params["subscriber_id"],
+		occurrence:   occurrence,
+	}, nil
+}
+
+func (s *reminderService) CheckToken(ctx context.Context, action, token string) error {
+	_, err := s.parseToken(action, token)
+	return err
+}
+
+func (s *reminderService) Snooze(ctx context.Context, token string) error {
+	link, err := s.parseToken(model.LinkActionSnooze, token)
+	if err != nil {
+		return err
+	}
+	err = s.reminderRepository.Snooze(ctx, link.userId, link.subscriberId, link.occurrence)
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return service.ErrSubscriptionNotFound
+	}
+	if errors.Is(err, repository.ErrSnoozeTooLate) {
+		return service.ErrSnoozeTooLate
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during snoozing reminder", slog.String("error", err.Error()))
+		return errors.New("failed to snooze reminder")
+	}
+	return nil
+}
+
+func (s *reminderService) MarkCongratulated(ctx context.Context, token string) error {
+	link, err := s.parseToken(model.LinkActionCongratulated, token)
+	if err != nil {
+		return err
+	}
+
+	err = s.reminderRepository.MarkCongratulated(ctx, link.userId, link.subscriberId, link.occurrence)
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return service.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during marking reminder as congratulated", slog.String("error", err.Error()))
+		return errors.New("failed to mark as congratulated")
+	}
+	return nil
+}
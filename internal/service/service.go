@@ -6,25 +6,61 @@ import (
 	"time"
 
 	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/pkg/telegram"
 )
 
 var (
-	ErrDuplicateUser          = errors.New("duplicate user")
-	ErrInvalidPassword        = errors.New("invalid password")
-	ErrUserNotFound           = errors.New("user not found")
-	ErrDuplicateSubscription  = errors.New("duplicate subscription")
-	ErrSubscriptionNotFound   = errors.New("subscription not found")
-	ErrOperationResultUnknown = errors.New("operation result unknown")
+	ErrDuplicateUser             = errors.New("duplicate user")
+	ErrInvalidPassword           = errors.New("invalid password")
+	ErrUserNotFound              = errors.New("user not found")
+	ErrDuplicateSubscription     = errors.New("duplicate subscription")
+	ErrSubscriptionNotFound      = errors.New("subscription not found")
+	ErrOperationResultUnknown    = errors.New("operation result unknown")
+	ErrChannelNotFound           = errors.New("channel not found")
+	ErrChatWebhookNotFound       = errors.New("chat webhook not found")
+	ErrWebhookNotFound           = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrInvalidWebhookUrl         = errors.New("webhook url must be a public http or https url")
+	ErrInvalidChatWebhookUrl     = errors.New("chat webhook url must be a public http or https url")
+	ErrPushSubscriptionNotFound  = errors.New("push subscription not found")
+	ErrInvalidPushKeys           = errors.New("invalid push subscription keys")
+	ErrInboxNotificationNotFound = errors.New("inbox notification not found")
+	ErrInvalidLinkToken          = errors.New("invalid link token")
+	ErrSnoozeTooLate             = errors.New("birthday is too close to snooze the reminder")
+	ErrVacationNotFound          = errors.New("vacation not found")
+	ErrInvalidVacationPeriod     = errors.New("vacation must not end before it starts")
+	ErrInvalidDelegate           = errors.New("reminders cannot be delegated to yourself")
+	ErrInvalidMuteDate           = errors.New("subscription cannot be muted until a past date")
+	ErrSubscriptionRuleNotFound  = errors.New("subscription rule not found")
+	ErrEmailChangeUnavailable    = errors.New("email change is not available")
+	ErrEmailAlreadyVerified      = errors.New("email is already verified")
+	ErrVerificationTooFrequent   = errors.New("verification email was sent recently, please try again later")
+	ErrTokenRevoked              = errors.New("token is revoked")
+	ErrInvalidResetToken         = errors.New("password reset token is invalid or expired")
+	ErrInvalidRefreshToken       = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused        = errors.New("refresh token was already used, session is revoked")
+	ErrInvalidInboxCursor        = errors.New("cursor does not point to a notification in your inbox")
+	ErrUnverifiedEmailAddress    = errors.New("email channel address must be the email of your account")
+	ErrInvalidUserCursor         = errors.New("cursor does not point to a user in the directory")
 )
 
 type Token struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type AuthService interface {
 	SignUp(ctx context.Context, email, password, name, surname string, birthdayDate time.Time) (Token, error)
 	SignIn(ctx context.Context, email, password string) (Token, error)
-	VerifyToken(ctx context.Context, tokenString string) (string, error)
+	VerifyToken(ctx context.Context, tokenString string) (string, string, error)
+	Refresh(ctx context.Context, refreshToken string) (Token, error)
+	Logout(ctx context.Context, tokenId string) error
+	ResendVerification(ctx context.Context, userId string) error
+	CheckVerificationToken(ctx context.Context, token string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	ChangePassword(ctx context.Context, userId, oldPassword, newPassword string) (Token, error)
 }
 
 type NotificationService interface {
@@ -32,13 +68,127 @@ type NotificationService interface {
 }
 
 type SubscriptionService interface {
-	CreateSubscription(ctx context.Context, userId, subscriberId string, notifyBeforeDays int) error
+	CreateSubscription(
+		ctx context.Context, userId, subscriberId string, notifyBeforeDays int, mutedUntil *time.Time,
+	) error
+	MuteSubscription(ctx context.Context, userId, subscriberId string, mutedUntil *time.Time) error
+	UpdateSubscription(
+		ctx context.Context, userId, subscriberId string, update model.SubscriptionUpdate,
+	) (model.Subscription, error)
 	DeleteSubscription(ctx context.Context, userId, subscriberId string) error
+	CreateSubscriptions(
+		ctx context.Context, subscriberId string, subscriptions []model.NewSubscription, atomic bool,
+	) ([]model.SubscriptionResult, error)
+	DeleteSubscriptions(
+		ctx context.Context, subscriberId string, userIds []string, atomic bool,
+	) ([]model.SubscriptionResult, error)
+	FindRules(ctx context.Context, subscriberId string) ([]model.SubscriptionRule, error)
+	SaveRule(ctx context.Context, subscriberId, rule string, notifyBeforeDays int) (model.SubscriptionRule, error)
+	DeleteRule(ctx context.Context, subscriberId, rule string) error
 }
 
 type UserService interface {
-	FindAllUsers(ctx context.Context, userId string) ([]model.User, error)
-	FindUsersSubscribedTo(ctx context.Context, userId string) ([]model.User, error)
+	FindAllUsers(
+		ctx context.Context, userId, search, sort, cursor string, limit int,
+	) ([]model.DirectoryUser, string, error)
+	FindUsersSubscribedTo(ctx context.Context, userId, sort string) ([]model.SubscriptionDetails, error)
+	FindSubscribers(ctx context.Context, userId string) ([]model.User, error)
+	UpdateChatHandle(ctx context.Context, userId string, chatHandle *string) error
+	UpdatePhone(ctx context.Context, userId string, phone *string) error
+	UpdateWorkplace(ctx context.Context, userId string, department, office *string) error
+	FindProfile(ctx context.Context, userId string) (model.User, error)
+	FindUser(ctx context.Context, userId string) (model.User, error)
+	UpdateProfile(ctx context.Context, userId string, update model.UserUpdate) (model.User, bool, error)
+	CheckEmailToken(ctx context.Context, token string) error
+	ConfirmEmail(ctx context.Context, token string) error
+}
+
+type ChannelService interface {
+	FindUserChannels(ctx context.Context, userId string) ([]model.UserChannel, error)
+	SaveUserChannel(ctx context.Context, userId, channel, address string, enabled bool) error
+	DeleteUserChannel(ctx context.Context, userId, channel string) error
+	FindSubscriptionChannels(ctx context.Context, userId, subscriberId string) ([]model.SubscriptionChannel, error)
+	SaveSubscriptionChannel(
+		ctx context.Context, userId, subscriberId, channel string, address *string, enabled bool,
+	) error
+	DeleteSubscriptionChannel(ctx context.Context, userId, subscriberId, channel string) error
+}
+
+type TelegramService interface {
+	CreateLinkCode(ctx context.Context, userId string) (model.TelegramLinkCode, error)
+	HandleUpdate(ctx context.Context, update telegram.Update) error
+}
+
+type ChatWebhookService interface {
+	CreateWebhook(ctx context.Context, webhook model.ChatWebhook) (model.ChatWebhook, error)
+	FindWebhooks(ctx context.Context, ownerId string) ([]model.ChatWebhook, error)
+	DeleteWebhook(ctx context.Context, id, ownerId string) error
+}
+
+type AnnouncementService interface {
+	Announce(ctx context.Context) error
+}
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, ownerId, url string, events []string) (model.Webhook, error)
+	FindWebhooks(ctx context.Context, ownerId string) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id, ownerId string) error
+	FindDeliveries(ctx context.Context, webhookId, ownerId, status string, limit int) ([]model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryId, ownerId string) (model.WebhookDelivery, error)
+	Publish(ctx context.Context, event string, data any, ownerIds ...string)
+	PublishToSubscribers(ctx context.Context, event string, data any, userId string)
+	PublishBirthdays(ctx context.Context) error
+	RedeliverPending(ctx context.Context) error
+	Wait()
+}
+
+type PushService interface {
+	SaveSubscription(ctx context.Context, userId, endpoint, p256dh, auth string) (model.PushSubscription, error)
+	DeleteSubscription(ctx context.Context, userId, endpoint string) error
+}
+
+type InboxService interface {
+	FindNotifications(
+		ctx context.Context, userId, cursor string, unreadOnly bool, limit int,
+	) ([]model.InboxNotification, string, error)
+	CountUnread(ctx context.Context, userId string) (int, error)
+	MarkRead(ctx context.Context, userId, id string) error
+	MarkManyRead(ctx context.Context, userId string, ids []string) (int64, error)
+	MarkAllRead(ctx context.Context, userId string) (int64, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type UnsubscribeService interface {
+	CheckToken(ctx context.Context, token string) error
+	Unsubscribe(ctx context.Context, token string, allEmails bool) error
+}
+
+type AccountService interface {
+	ScheduleDeletion(ctx context.Context, userId, password string) (time.Time, error)
+	CancelDeletion(ctx context.Context, userId string) error
+	Export(ctx context.Context, userId string) (model.AccountExport, error)
+	DeleteScheduled(ctx context.Context) error
+}
+
+type ReminderService interface {
+	CheckToken(ctx context.Context, action, token string) error
+	Snooze(ctx context.Context, token string) error
+	MarkCongratulated(ctx context.Context, token string) error
+}
+
+type SettingsService interface {
+	FindVacation(ctx context.Context, userId string) (model.Vacation, error)
+	SaveVacation(
+		ctx context.Context, userId string, from, until time.Time, delegateId *string,
+	) (model.Vacation, error)
+	DeleteVacation(ctx context.Context, userId string) error
+	FindPrivacy(ctx context.Context, userId string) (model.Privacy, error)
+	SavePrivacy(ctx context.Context, userId string, privacy model.Privacy) error
+}
+
+type BirthdayService interface {
+	FindUpcomingBirthdays(ctx context.Context, userId, scope string, days int) ([]model.UserBirthday, error)
+	FindCalendar(ctx context.Context, userId, scope string, year, month int) ([]model.CalendarDay, error)
 }
 
 type Service struct {
@@ -46,4 +196,16 @@ type Service struct {
 	Notification NotificationService
 	Subscription SubscriptionService
 	User         UserService
+	Channel      ChannelService
+	Telegram     TelegramService
+	ChatWebhook  ChatWebhookService
+	Announcement AnnouncementService
+	Webhook      WebhookService
+	Push         PushService
+	Inbox        InboxService
+	Unsubscribe  UnsubscribeService
+	Reminder     ReminderService
+	Settings     SettingsService
+	Birthday     BirthdayService
+	Account      AccountService
 }
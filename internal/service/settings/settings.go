@@ -0,0 +1,94 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+type settingsService struct {
+	settingsRepository repository.SettingsRepository
+	logger             *slog.Logger
+}
+
+func NewSettingsService(settingsRepository repository.SettingsRepository, logger *slog.Logger) *settingsService {
+	return &settingsService{
+		settingsRepository: settingsRepository,
+		logger:             logger,
+	}
+}
+
+func (s *settingsService) FindVacation(ctx context.Context, userId string) (model.Vacation, error) {
+	vacation, err := s.settingsRepository.FindVacation(ctx, userId)
+	if errors.Is(err, repository.ErrVacationNotFound) {
+		return model.Vacation{}, service.ErrVacationNotFound
+	}
+	if err != nil {
+		return model.Vacation{}, errors.New("failed to find vacation")
+	}
+	return vacation, nil
+}
+
+func (s *settingsService) SaveVacation(
+	ctx context.Context, userId string, from, until time.Time, delegateId *string,
+) (model.Vacation, error) {
+	if until.Before(from) {
+		return model.Vacation{}, service.ErrInvalidVacationPeriod
+	}
+	if delegateId != nil && *delegateId == userId {
+		return model.Vacation{}, service.ErrInvalidDelegate
+	}
+
+	err := s.settingsRepository.SaveVacation(ctx, userId, from, until, delegateId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return model.Vacation{}, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during saving vacation", slog.String("error", err.Error()))
+		return model.Vacation{}, errors.New("failed to save vacation")
+	}
+	return model.Vacation{
+		From:       from.Format(time.DateOnly),
+		Until:      until.Format(time.DateOnly),
+		DelegateId: delegateId,
+	}, nil
+}
+
+func (s *settingsService) DeleteVacation(ctx context.Context, userId string) error {
+	err := s.settingsRepository.DeleteVacation(ctx, userId)
+	if errors.Is(err, repository.ErrVacationNotFound) {
+		return service.ErrVacationNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete vacation")
+	}
+	return nil
+}
+
+func (s *settingsService) FindPrivacy(ctx context.Context, userId string) (model.Privacy, error) {
+	privacy, err := s.settingsRepository.FindPrivacy(ctx, userId)
+	if err != nil {
+		return model.Privacy{}, errors.New("failed to find privacy settings")
+	}
+	return privacy, nil
+}
+
+func (s *settingsService) SavePrivacy(ctx context.Context, userId string, privacy model.Privacy) error {
+	err := s.settingsRepository.SavePrivacy(ctx, userId, privacy)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during saving privacy settings", slog.String("error", err.Error()))
+		return errors.New("failed to save privacy settings")
+	}
+	return nil
+}
@@ -3,31 +3,50 @@ package subscription
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
 )
 
 type subscriptionService struct {
 	subscriptionRepository repository.SubscriptionRepository
+	userRepository         repository.UserRepository
+	settingsRepository     repository.SettingsRepository
+	inboxRepository        repository.InboxRepository
+	webhookService         service.WebhookService
 	logger                 *slog.Logger
 }
 
 func NewSubscriptionService(
 	subscriptionRepository repository.SubscriptionRepository,
+	userRepository repository.UserRepository,
+	settingsRepository repository.SettingsRepository,
+	inboxRepository repository.InboxRepository,
+	webhookService service.WebhookService,
 	logger *slog.Logger,
 ) *subscriptionService {
 	return &subscriptionService{
 		subscriptionRepository: subscriptionRepository,
+		userRepository:         userRepository,
+		settingsRepository:     settingsRepository,
+		inboxRepository:        inboxRepository,
+		webhookService:         webhookService,
 		logger:                 logger,
 	}
 }
 
 func (s *subscriptionService) CreateSubscription(
-	ctx context.Context, userId, subscriberId string, notifyBeforeDays int,
+	ctx context.Context, userId, subscriberId string, notifyBeforeDays int, mutedUntil *time.Time,
 ) error {
-	err := s.subscriptionRepository.CreateSubscription(ctx, userId, subscriberId, notifyBeforeDays)
+	if mutedUntil != nil && isPastDate(*mutedUntil) {
+		return service.ErrInvalidMuteDate
+	}
+
+	err := s.subscriptionRepository.CreateSubscription(ctx, userId, subscriberId, notifyBeforeDays, mutedUntil)
 	if errors.Is(err, repository.ErrUserNotFound) {
 		return service.ErrUserNotFound
 	}
@@ -37,9 +56,52 @@ func (s *subscriptionService) CreateSubscription(
 	if err != nil {
 		return errors.New("failed to create subscription")
 	}
+
+	subscription := newSubscription(userId, subscriberId, notifyBeforeDays, mutedUntil)
+	s.publishSubscription(ctx, subscription)
+	s.notifySubscribed(ctx, userId, subscriberId)
 	return nil
 }
 
+func (s *subscriptionService) MuteSubscription(
+	ctx context.Context, userId, subscriberId string, mutedUntil *time.Time,
+) error {
+	if mutedUntil != nil && isPastDate(*mutedUntil) {
+		return service.ErrInvalidMuteDate
+	}
+
+	err := s.subscriptionRepository.MuteSubscription(ctx, userId, subscriberId, mutedUntil)
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return service.ErrSubscriptionNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during muting subscription", slog.String("error", err.Error()))
+		return errors.New("failed to mute subscription")
+	}
+	return nil
+}
+
+func (s *subscriptionService) UpdateSubscription(
+	ctx context.Context, userId, subscriberId string, update model.SubscriptionUpdate,
+) (model.Subscription, error) {
+	if update.SetMutedUntil && update.MutedUntil != nil && isPastDate(*update.MutedUntil) {
+		return model.Subscription{}, service.ErrInvalidMuteDate
+	}
+
+	subscription, err := s.subscriptionRepository.UpdateSubscription(ctx, userId, subscriberId, update)
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return model.Subscription{}, service.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during updating subscription", slog.String("error", err.Error()))
+		return model.Subscription{}, errors.New("failed to update subscription")
+	}
+	return subscription, nil
+}
+
 func (s *subscriptionService) DeleteSubscription(ctx context.Context, userId, subscriberId string) error {
 	err := s.subscriptionRepository.DeleteSubscription(ctx, userId, subscriberId)
 	if errors.Is(err, repository.ErrSubscriptionNotFound) {
@@ -53,3 +115,153 @@ func (s *subscriptionService) DeleteSubscription(ctx context.Context, userId, su
 	}
 	return nil
 }
+
+func (s *subscriptionService) FindRules(ctx context.Context, subscriberId string) ([]model.SubscriptionRule, error) {
+	rules, err := s.subscriptionRepository.FindRules(ctx, subscriberId)
+	if err != nil {
+		return nil, errors.New("failed to find subscription rules")
+	}
+	return rules, nil
+}
+
+func (s *subscriptionService) SaveRule(
+	ctx context.Context, subscriberId, rule string, notifyBeforeDays int,
+) (model.SubscriptionRule, error) {
+	subscriptionRule, err := s.subscriptionRepository.SaveRule(ctx, subscriberId, rule, notifyBeforeDays)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return model.SubscriptionRule{}, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during saving subscription rule", slog.String("error", err.Error()))
+		return model.SubscriptionRule{}, errors.New("failed to save subscription rule")
+	}
+	return subscriptionRule, nil
+}
+
+func (s *subscriptionService) DeleteRule(ctx context.Context, subscriberId, rule string) error {
+	err := s.subscriptionRepository.DeleteRule(ctx, subscriberId, rule)
+	if errors.Is(err, repository.ErrSubscriptionRuleNotFound) {
+		return service.ErrSubscriptionRuleNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete subscription rule")
+	}
+	return nil
+}
+
+// publishSubscription delivers the subscription to webhooks of both its sides,
+// the user subscribed to is left out if the subscriber hides their subscriptions
+func (s *subscriptionService) publishSubscription(ctx context.Context, subscription model.Subscription) {
+	ownerIds := []string{subscription.SubscriberId}
+	privacy, err := s.settingsRepository.FindPrivacy(ctx, subscription.SubscriberId)
+	if err != nil {
+		s.logger.Error("error during finding privacy settings", slog.String("error", err.Error()))
+	}
+	if err == nil && !privacy.HideSubscriptions {
+		ownerIds = append(ownerIds, subscription.UserId)
+	}
+	s.webhookService.Publish(ctx, model.EventSubscriptionCreated, subscription, ownerIds...)
+}
+
+// notifySubscribed puts a notice about the new subscriber into the inbox of the user, if the user asked for it
+// and the subscriber does not hide their subscriptions. subscription is already created at this point,
+// so failures are only logged
+func (s *subscriptionService) notifySubscribed(ctx context.Context, userId, subscriberId string) {
+	privacy, err := s.settingsRepository.FindPrivacy(ctx, userId)
+	if err != nil {
+		s.logger.Error("error during finding privacy settings", slog.String("error", err.Error()))
+		return
+	}
+	if !privacy.NotifyNewSubscribers {
+		return
+	}
+	subscriberPrivacy, err := s.settingsRepository.FindPrivacy(ctx, subscriberId)
+	if err != nil {
+		s.logger.Error("error during finding privacy settings", slog.String("error", err.Error()))
+		return
+	}
+	if subscriberPrivacy.HideSubscriptions {
+		return
+	}
+	subscriber, err := s.userRepository.FindById(ctx, subscriberId)
+	if err != nil {
+		s.logger.Error("error during finding subscriber", slog.String("error", err.Error()))
+		return
+	}
+
+	// the notice is about the subscriber, so it is referenced instead of the birthday user
+	notification := model.InboxNotification{
+		UserId:         userId,
+		BirthdayUserId: subscriberId,
+		Subject:        "New subscriber",
+		Body:           fmt.Sprintf("%s %s has subscribed to your birthday reminders.", subscriber.Name, subscriber.Surname),
+	}
+	err = s.inboxRepository.CreateNotifications(ctx, []model.InboxNotification{notification})
+	if err != nil {
+		s.logger.Error("error during saving new subscriber notice", slog.String("error", err.Error()))
+	}
+}
+
+func newSubscription(userId, subscriberId string, notifyBeforeDays int, mutedUntil *time.Time) model.Subscription {
+	subscription := model.Subscription{
+		UserId:           userId,
+		SubscriberId:     subscriberId,
+		NotifyBeforeDays: notifyBeforeDays,
+	}
+	if mutedUntil != nil {
+		date := mutedUntil.Format(time.DateOnly)
+		subscription.MutedUntil = &date
+	}
+	return subscription
+}
+
+// isPastDate reports whether date is before today. mute dates are inclusive,
+// so muting until today silences today's reminders.
+func isPastDate(date time.Time) bool {
+	return date.Format(time.DateOnly) < time.Now().Format(time.DateOnly)
+}
+
+func (s *subscriptionService) CreateSubscriptions(
+	ctx context.Context, subscriberId string, subscriptions []model.NewSubscription, atomic bool,
+) ([]model.SubscriptionResult, error) {
+	for _, subscription := range subscriptions {
+		if subscription.MutedUntil != nil && isPastDate(*subscription.MutedUntil) {
+			return nil, service.ErrInvalidMuteDate
+		}
+	}
+
+	results, err := s.subscriptionRepository.CreateSubscriptions(ctx, subscriberId, subscriptions, atomic)
+	if err != nil {
+		s.logger.Error("error during creating subscriptions", slog.String("error", err.Error()))
+		return nil, errors.New("failed to create subscriptions")
+	}
+
+	for i, result := range results {
+		if result.Status != model.SubscriptionStatusCreated {
+			continue
+		}
+		subscription := newSubscription(
+			result.UserId, subscriberId, subscriptions[i].NotifyBeforeDays, subscriptions[i].MutedUntil,
+		)
+		s.publishSubscription(ctx, subscription)
+		s.notifySubscribed(ctx, result.UserId, subscriberId)
+	}
+	return results, nil
+}
+
+func (s *subscriptionService) DeleteSubscriptions(
+	ctx context.Context, subscriberId string, userIds []string, atomic bool,
+) ([]model.SubscriptionResult, error) {
+	results, err := s.subscriptionRepository.DeleteSubscriptions(ctx, subscriberId, userIds, atomic)
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return nil, service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during deleting subscriptions", slog.String("error", err.Error()))
+		return nil, errors.New("failed to delete subscriptions")
+	}
+	return results, nil
+}
@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/pkg/telegram"
+)
+
+const (
+	linkCodeLength   = 8
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	startCommand     = "/start"
+)
+
+type telegramService struct {
+	telegramRepository repository.TelegramRepository
+	channelRepository  repository.ChannelRepository
+	client             telegram.Client
+	linkCodeTtl        time.Duration
+	logger             *slog.Logger
+}
+
+func NewTelegramService(
+	telegramRepository repository.TelegramRepository,
+	channelRepository repository.ChannelRepository,
+	client telegram.Client,
+	linkCodeTtl time.Duration,
+	logger *slog.Logger,
+) *telegramService {
+	return &telegramService{
+		telegramRepository: telegramRepository,
+		channelRepository:  channelRepository,
+		client:             client,
+		linkCodeTtl:        linkCodeTtl,
+		logger:             logger,
+	}
+}
+
+func generateLinkCode() (string, error) {
+	code := make([]byte, linkCodeLength)
+	max := big.NewInt(int64(len(linkCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = linkCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func (s *telegramService) CreateLinkCode(ctx context.Context, userId string) (model.TelegramLinkCode, error) {
+	emptyResponse := model.TelegramLinkCode{}
+	code, err := generateLinkCode()
+	if err != nil {
+		s.logger.Error("error during link code generation", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to create link code")
+	}
+
+	expiresAt := time.Now().Add(s.linkCodeTtl)
+	err = s.telegramRepository.CreateLinkCode(ctx, userId, code, expiresAt)
+	if err != nil {
+		s.logger.Error("error during saving link code", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to create link code")
+	}
+
+	return model.TelegramLinkCode{Code: code, ExpiresAt: expiresAt}, nil
+}
+
+func (s *telegramService) HandleUpdate(ctx context.Context, update telegram.Update) error {
+	if update.Message == nil {
+		return nil
+	}
+	// code can be sent either as a plain message or through a deep link, e.g. "/start CODE"
+	text := strings.TrimSpace(update.Message.Text)
+	text = strings.TrimSpace(strings.TrimPrefix(text, startCommand))
+	if text == "" {
+		return nil
+	}
+	chatId := strconv.FormatInt(update.Message.Chat.Id, 10)
+
+	userId, err := s.telegramRepository.ConsumeLinkCode(ctx, strings.ToUpper(text))
+	if errors.Is(err, repository.ErrLinkCodeNotFound) {
+		return s.reply(ctx, chatId, "Code is invalid or expired. Please request a new one.")
+	}
+	if err != nil {
+		s.logger.Error("error during consuming link code", slog.String("error", err.Error()))
+		return errors.New("failed to link account")
+	}
+
+	err = s.channelRepository.SaveUserChannel(ctx, userId, model.ChannelTelegram, chatId, true)
+	if err != nil {
+		s.logger.Error("error during saving telegram channel", slog.String("error", err.Error()))
+		return errors.New("failed to link account")
+	}
+
+	return s.reply(ctx, chatId, "Your account is linked. Birthday reminders will be sent to this chat.")
+}
+
+func (s *telegramService) reply(ctx context.Context, chatId, text string) error {
+	if err := s.client.SendMessage(ctx, chatId, text); err != nil {
+		s.logger.Error("error during replying to telegram chat", slog.String("error", err.Error()))
+		return errors.New("failed to reply to telegram chat")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
+)
+
+type unsubscribeService struct {
+	subscriptionRepository repository.SubscriptionRepository
+	channelRepository      repository.ChannelRepository
+	linkManager            linktoken.Manager
+	logger                 *slog.Logger
+}
+
+func NewUnsubscribeService(
+	subscriptionRepository repository.SubscriptionRepository,
+	channelRepository repository.ChannelRepository,
+	linkManager linktoken.Manager,
+	logger *slog.Logger,
+) *unsubscribeService {
+	return &unsubscribeService{
+		subscriptionRepository: subscriptionRepository,
+		channelRepository:      channelRepository,
+		linkManager:            linkManager,
+		logger:                 logger,
+	}
+}
+
+func (s *unsubscribeService) parseToken(token string) (string, string, error) {
+	params, err := s.linkManager.ParseToken(model.LinkActionUnsubscribe, token)
+	if err != nil || params["user_id"] == "" || params["subscriber_id"] == "" {
+		return "", "", service.ErrInvalidLinkToken
+	}
+	return params["user_id"], params["subscriber_id"], nil
+}
+
+func (s *unsubscribeService) CheckToken(ctx context.Context, token string) error {
+	_, _, err := s.parseToken(token)
+	return err
+}
+
+func (s *unsubscribeService) Unsubscribe(ctx context.Context, token string, allEmails bool) error {
+	userId, subscriberId, err := s.parseToken(token)
+	if err != nil {
+		return err
+	}
+
+	if allEmails {
+		err := s.channelRepository.DisableEmailChannel(ctx, subscriberId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return service.ErrUserNotFound
+		}
+		if errors.Is(err, repository.ErrQueryResultUnknown) {
+			return service.ErrOperationResultUnknown
+		}
+		if err != nil {
+			s.logger.Error("error during disabling email channel", slog.String("error", err.Error()))
+			return errors.New("failed to unsubscribe")
+		}
+		return nil
+	}
+
+	err = s.subscriptionRepository.DeleteSubscription(ctx, userId, subscriberId)
+	// link may be followed several times, e.g. by mail client and then by user
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return nil
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during deleting subscription", slog.String("error", err.Error()))
+		return errors.New("failed to unsubscribe")
+	}
+	return nil
+}
@@ -3,36 +3,266 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/url"
+	"strings"
 
 	"github.com/vshevchenk0/bday-notifier/internal/model"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
+	"github.com/vshevchenk0/bday-notifier/pkg/mailer"
 )
 
+type UserServiceConfig struct {
+	// PublicUrl is the base url of the api used in email confirmation links,
+	// email cannot be changed when it is empty
+	PublicUrl string
+}
+
 type userService struct {
 	userRepository repository.UserRepository
+	linkManager    linktoken.Manager
+	mailer         mailer.Mailer
+	publicUrl      string
 	logger         *slog.Logger
 }
 
-func NewUserService(userRepository repository.UserRepository, logger *slog.Logger) *userService {
+func NewUserService(
+	userRepository repository.UserRepository,
+	linkManager linktoken.Manager,
+	mailer mailer.Mailer,
+	config *UserServiceConfig,
+	logger *slog.Logger,
+) *userService {
 	return &userService{
 		userRepository: userRepository,
+		linkManager:    linkManager,
+		mailer:         mailer,
+		publicUrl:      strings.TrimRight(config.PublicUrl, "/"),
 		logger:         logger,
 	}
 }
 
-func (s *userService) FindAllUsers(ctx context.Context, userId string) ([]model.User, error) {
-	users, err := s.userRepository.FindAllUsers(ctx, userId)
+// maxSearchWords limits the number of words search query is split into
+const maxSearchWords = 5
+
+func (s *userService) FindAllUsers(
+	ctx context.Context, userId, search, sort, cursor string, limit int,
+) ([]model.DirectoryUser, string, error) {
+	words := strings.Fields(search)
+	if len(words) > maxSearchWords {
+		words = words[:maxSearchWords]
+	}
+	// one extra row is requested to find out whether the next page exists
+	users, err := s.userRepository.FindAllUsers(ctx, userId, words, sort, cursor, limit+1)
+	if errors.Is(err, repository.ErrUserCursorNotFound) {
+		return nil, "", service.ErrInvalidUserCursor
+	}
 	if err != nil {
-		return nil, errors.New("failed to find users")
+		s.logger.Error("error during finding users", slog.String("error", err.Error()))
+		return nil, "", errors.New("failed to find users")
 	}
-	return users, nil
+	nextCursor := ""
+	if len(users) > limit {
+		users = users[:limit]
+		nextCursor = users[limit-1].Id
+	}
+	return users, nextCursor, nil
 }
 
-func (s *userService) FindUsersSubscribedTo(ctx context.Context, userId string) ([]model.User, error) {
-	users, err := s.userRepository.FindUsersSubscribedTo(ctx, userId)
+func (s *userService) FindUsersSubscribedTo(
+	ctx context.Context, userId, sort string,
+) ([]model.SubscriptionDetails, error) {
+	users, err := s.userRepository.FindUsersSubscribedTo(ctx, userId, sort)
 	if err != nil {
 		return nil, errors.New("failed to find users subscribed to")
 	}
 	return users, nil
 }
+
+func (s *userService) FindSubscribers(ctx context.Context, userId string) ([]model.User, error) {
+	users, err := s.userRepository.FindSubscribers(ctx, userId)
+	if err != nil {
+		return nil, errors.New("failed to find subscribers")
+	}
+	return users, nil
+}
+
+func (s *userService) UpdateChatHandle(ctx context.Context, userId string, chatHandle *string) error {
+	err := s.userRepository.UpdateChatHandle(ctx, userId, chatHandle)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during updating chat handle", slog.String("error", err.Error()))
+		return errors.New("failed to update chat handle")
+	}
+	return nil
+}
+
+func (s *userService) UpdatePhone(ctx context.Context, userId string, phone *string) error {
+	err := s.userRepository.UpdatePhone(ctx, userId, phone)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during updating phone", slog.String("error", err.Error()))
+		return errors.New("failed to update phone")
+	}
+	return nil
+}
+
+func (s *userService) UpdateWorkplace(ctx context.Context, userId string, department, office *string) error {
+	err := s.userRepository.UpdateWorkplace(ctx, userId, department, office)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during updating workplace", slog.String("error", err.Error()))
+		return errors.New("failed to update workplace")
+	}
+	return nil
+}
+
+func (s *userService) FindProfile(ctx context.Context, userId string) (model.User, error) {
+	user, err := s.userRepository.FindProfile(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return user, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during finding profile", slog.String("error", err.Error()))
+		return user, errors.New("failed to find profile")
+	}
+	return user, nil
+}
+
+func (s *userService) FindUser(ctx context.Context, userId string) (model.User, error) {
+	user, err := s.userRepository.FindById(ctx, userId)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return user, service.ErrUserNotFound
+	}
+	if err != nil {
+		s.logger.Error("error during finding user", slog.String("error", err.Error()))
+		return user, errors.New("failed to find user")
+	}
+	return user, nil
+}
+
+// UpdateProfile applies the update right away except for email, which is changed
+// only after the new address is confirmed. The returned flag reports whether
+// the confirmation was sent
+func (s *userService) UpdateProfile(
+	ctx context.Context, userId string, update model.UserUpdate,
+) (model.User, bool, error) {
+	profile, err := s.FindProfile(ctx, userId)
+	if err != nil {
+		return profile, false, err
+	}
+
+	changeEmail := update.Email != nil && !strings.EqualFold(*update.Email, profile.Email)
+	// email is checked before anything is saved, so that the request either fails or succeeds as a whole
+	if changeEmail {
+		if s.publicUrl == "" {
+			return profile, false, service.ErrEmailChangeUnavailable
+		}
+		_, err := s.userRepository.FindByEmail(ctx, *update.Email)
+		if err == nil {
+			return profile, false, service.ErrDuplicateUser
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			s.logger.Error("error during finding user by email", slog.String("error", err.Error()))
+			return profile, false, errors.New("failed to update profile")
+		}
+	}
+
+	if update.Name != nil || update.Surname != nil || update.BirthdayDate != nil {
+		profile, err = s.userRepository.UpdateUser(ctx, userId, update)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return profile, false, service.ErrUserNotFound
+		}
+		if err != nil {
+			s.logger.Error("error during updating profile", slog.String("error", err.Error()))
+			return profile, false, errors.New("failed to update profile")
+		}
+	}
+
+	if !changeEmail {
+		return profile, false, nil
+	}
+	if err := s.sendEmailConfirmation(ctx, profile, *update.Email); err != nil {
+		s.logger.Error("error during sending email confirmation", slog.String("error", err.Error()))
+		return profile, false, errors.New("profile updated, but failed to send email confirmation. please try again")
+	}
+	return profile, true, nil
+}
+
+func (s *userService) sendEmailConfirmation(ctx context.Context, profile model.User, email string) error {
+	token, err := s.linkManager.NewToken(model.LinkActionConfirmEmail, map[string]string{
+		"user_id":   profile.Id,
+		"old_email": profile.Email,
+		"email":     email,
+	})
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/api/users/email/confirm?token=%s", s.publicUrl, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Hi, %s!\n\nPlease confirm that you want to receive birthday reminders at this address:\n%s\n\n"+
+			"If you did not request this change, just ignore this email.",
+		profile.Name, link,
+	)
+	return s.mailer.Send(ctx, []string{email}, "Confirm your email", body)
+}
+
+type emailChange struct {
+	userId   string
+	oldEmail string
+	email    string
+}
+
+func (s *userService) parseEmailToken(token string) (emailChange, error) {
+	params, err := s.linkManager.ParseToken(model.LinkActionConfirmEmail, token)
+	if err != nil || params["user_id"] == "" || params["old_email"] == "" || params["email"] == "" {
+		return emailChange{}, service.ErrInvalidLinkToken
+	}
+	return emailChange{
+		userId:   params["user_id"],
+		oldEmail: params["old_email"],
+		email:    params["email"],
+	}, nil
+}
+
+func (s *userService) CheckEmailToken(ctx context.Context, token string) error {
+	_, err := s.parseEmailToken(token)
+	return err
+}
+
+func (s *userService) ConfirmEmail(ctx context.Context, token string) error {
+	change, err := s.parseEmailToken(token)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepository.UpdateEmail(ctx, change.userId, change.oldEmail, change.email)
+	if errors.Is(err, repository.ErrEmailIsNotUnique) {
+		return service.ErrDuplicateUser
+	}
+	// email was changed after the link had been sent, so the link is stale
+	if errors.Is(err, repository.ErrUserNotFound) {
+		profile, findErr := s.userRepository.FindProfile(ctx, change.userId)
+		if findErr == nil && profile.Email == change.email {
+			return nil
+		}
+		return service.ErrInvalidLinkToken
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		s.logger.Error("error during confirming email", slog.String("error", err.Error()))
+		return errors.New("failed to confirm email")
+	}
+	return nil
+}
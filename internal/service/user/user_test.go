@@ -0,0 +1,143 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+)
+
+const testUserId = "0b7a4c55-3f0c-4a6e-9a57-0f1c6f1ee8a1"
+
+// fakeUserRepository keeps the directory as seen by testUserId, ordered by the page order
+type fakeUserRepository struct {
+	repository.UserRepository
+	directory  []model.DirectoryUser
+	emails     map[string]string
+	findErr    error
+	updateUser func(update model.UserUpdate)
+}
+
+func (r *fakeUserRepository) FindProfile(ctx context.Context, userId string) (model.User, error) {
+	return model.User{Id: userId, Email: "john@example.com"}, nil
+}
+
+func (r *fakeUserRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	if r.findErr != nil {
+		return model.User{}, r.findErr
+	}
+	userId, ok := r.emails[email]
+	if !ok {
+		return model.User{}, repository.ErrUserNotFound
+	}
+	return model.User{Id: userId, Email: email}, nil
+}
+
+func (r *fakeUserRepository) UpdateUser(
+	ctx context.Context, userId string, update model.UserUpdate,
+) (model.User, error) {
+	if r.updateUser != nil {
+		r.updateUser(update)
+	}
+	return model.User{Id: userId, Email: "john@example.com"}, nil
+}
+
+func (r *fakeUserRepository) FindAllUsers(
+	ctx context.Context, userId string, search []string, sort, cursor string, limit int,
+) ([]model.DirectoryUser, error) {
+	start := 0
+	if cursor != "" {
+		start = -1
+		for i, user := range r.directory {
+			if user.Id == cursor {
+				start = i + 1
+			}
+		}
+		if start == -1 {
+			return nil, repository.ErrUserCursorNotFound
+		}
+	}
+	end := min(start+limit, len(r.directory))
+	return r.directory[start:end], nil
+}
+
+func newTestService() *userService {
+	userRepository := &fakeUserRepository{directory: []model.DirectoryUser{
+		{User: model.User{Id: "1f0e6a3c-61b6-4bde-9f43-6c1b0a2f9d11"}},
+		{User: model.User{Id: "2a7c9e51-0d3b-4c8f-a6e2-5b4d8f1c3e22"}},
+		{User: model.User{Id: "3c5b8d72-1e4a-4f9b-b7d3-6a2e9c0f4d33"}},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewUserService(userRepository, nil, nil, &UserServiceConfig{}, logger)
+}
+
+func TestFindAllUsersPages(t *testing.T) {
+	s := newTestService()
+
+	users, nextCursor, err := s.FindAllUsers(context.Background(), testUserId, "", model.UserSortName, "", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 2 || nextCursor != users[1].Id {
+		t.Fatalf("page = %d users with cursor %q, want 2 users with the last one as cursor", len(users), nextCursor)
+	}
+
+	users, nextCursor, err = s.FindAllUsers(context.Background(), testUserId, "", model.UserSortName, nextCursor, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || nextCursor != "" {
+		t.Errorf("page = %d users with cursor %q, want the last user without cursor", len(users), nextCursor)
+	}
+}
+
+func TestFindAllUsersUnknownCursor(t *testing.T) {
+	s := newTestService()
+
+	for _, cursor := range []string{"9d8c7b6a-5f4e-4d3c-8b2a-1f0e9d8c7b6a", testUserId} {
+		_, _, err := s.FindAllUsers(context.Background(), testUserId, "", model.UserSortName, cursor, 2)
+		if !errors.Is(err, service.ErrInvalidUserCursor) {
+			t.Errorf("cursor %s: error = %v, want ErrInvalidUserCursor", cursor, err)
+		}
+	}
+}
+
+func TestUpdateProfileEmailCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		findErr error
+		wantErr func(error) bool
+	}{
+		{"taken", "jane@example.com", nil, func(err error) bool { return errors.Is(err, service.ErrDuplicateUser) }},
+		{"lookup fails", "new@example.com", errors.New("connection refused"), func(err error) bool {
+			return err != nil && !errors.Is(err, service.ErrDuplicateUser)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated := false
+			userRepository := &fakeUserRepository{
+				emails:     map[string]string{"jane@example.com": "2a7c9e51-0d3b-4c8f-a6e2-5b4d8f1c3e22"},
+				findErr:    test.findErr,
+				updateUser: func(update model.UserUpdate) { updated = true },
+			}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := NewUserService(userRepository, nil, nil, &UserServiceConfig{PublicUrl: "https://example.com"}, logger)
+
+			name := "Johnny"
+			_, _, err := s.UpdateProfile(context.Background(), testUserId, model.UserUpdate{Name: &name, Email: &test.email})
+			if !test.wantErr(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if updated {
+				t.Error("profile was updated although the email check failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/internal/model"
+	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	"github.com/vshevchenk0/bday-notifier/internal/service"
+	"github.com/vshevchenk0/bday-notifier/pkg/webhook"
+)
+
+const (
+	secretPrefix = "whsec_"
+	secretLength = 32
+	// deliveryLease is the time a delivery is reserved for the process sending it,
+	// it should exceed the request timeout
+	deliveryLease       = 5 * time.Minute
+	redeliveryBatchSize = 100
+)
+
+type WebhookServiceConfig struct {
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	UpcomingDays int
+}
+
+type webhookService struct {
+	webhookRepository repository.WebhookRepository
+	sender            webhook.Sender
+	maxAttempts       int
+	retryBackoff      time.Duration
+	upcomingDays      int
+	wg                *sync.WaitGroup
+	logger            *slog.Logger
+}
+
+func NewWebhookService(
+	webhookRepository repository.WebhookRepository,
+	sender webhook.Sender,
+	config *WebhookServiceConfig,
+	logger *slog.Logger,
+) *webhookService {
+	return &webhookService{
+		webhookRepository: webhookRepository,
+		sender:            sender,
+		maxAttempts:       config.MaxAttempts,
+		retryBackoff:      config.RetryBackoff,
+		upcomingDays:      config.UpcomingDays,
+		wg:                &sync.WaitGroup{},
+		logger:            logger,
+	}
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, secretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(secret), nil
+}
+
+func (s *webhookService) CreateWebhook(
+	ctx context.Context, ownerId, url string, events []string,
+) (model.Webhook, error) {
+	if err := s.sender.ValidateUrl(ctx, url); err != nil {
+		s.logger.Info("rejected webhook url", slog.String("url", url), slog.String("error", err.Error()))
+		return model.Webhook{}, service.ErrInvalidWebhookUrl
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		s.logger.Error("error during webhook secret generation", slog.String("error", err.Error()))
+		return model.Webhook{}, errors.New("failed to create webhook")
+	}
+	webhook, err := s.webhookRepository.CreateWebhook(ctx, model.Webhook{
+		OwnerId: ownerId,
+		Url:     url,
+		Secret:  secret,
+		Events:  events,
+	})
+	if err != nil {
+		s.logger.Error("error during saving webhook", slog.String("error", err.Error()))
+		return model.Webhook{}, errors.New("failed to create webhook")
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) FindWebhooks(ctx context.Context, ownerId string) ([]model.Webhook, error) {
+	webhooks, err := s.webhookRepository.FindWebhooks(ctx, ownerId)
+	if err != nil {
+		return nil, errors.New("failed to find webhooks")
+	}
+	return webhooks, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id, ownerId string) error {
+	err := s.webhookRepository.DeleteWebhook(ctx, id, ownerId)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return service.ErrWebhookNotFound
+	}
+	if errors.Is(err, repository.ErrQueryResultUnknown) {
+		return service.ErrOperationResultUnknown
+	}
+	if err != nil {
+		return errors.New("failed to delete webhook")
+	}
+	return nil
+}
+
+func (s *webhookService) FindDeliveries(
+	ctx context.Context, webhookId, ownerId, status string, limit int,
+) ([]model.WebhookDelivery, error) {
+	deliveries, err := s.webhookRepository.FindDeliveries(ctx, webhookId, ownerId, status, limit)
+	if err != nil {
+		return nil, errors.New("failed to find webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, deliveryId, ownerId string) (model.WebhookDelivery, error) {
+	emptyResponse := model.WebhookDelivery{}
+	original, err := s.webhookRepository.FindDelivery(ctx, deliveryId, ownerId)
+	if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+		return emptyResponse, service.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return emptyResponse, errors.New("failed to find webhook delivery")
+	}
+
+	webhook, err := s.webhookRepository.FindWebhook(ctx, original.WebhookId)
+	if err != nil {
+		return emptyResponse, errors.New("failed to find webhook")
+	}
+
+	delivery, err := s.webhookRepository.CreateDelivery(
+		ctx, webhook.Id, original.Event, original.Payload, &original.Id, time.Now().Add(deliveryLease),
+	)
+	if err != nil {
+		s.logger.Error("error during saving webhook delivery", slog.String("error", err.Error()))
+		return emptyResponse, errors.New("failed to redeliver webhook")
+	}
+
+	s.dispatch(context.WithoutCancel(ctx), webhook, delivery)
+	return delivery, nil
+}
+
+// Publish delivers the event to webhooks of the owners in background, so it does not delay the caller
+func (s *webhookService) Publish(ctx context.Context, event string, data any, ownerIds ...string) {
+	ctx = context.WithoutCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		webhooks, err := s.webhookRepository.FindWebhooksForEvent(ctx, event, ownerIds)
+		if err != nil {
+			s.logger.Error("failed to find webhooks for event", slog.String("error", err.Error()))
+			return
+		}
+		s.publish(ctx, event, data, webhooks)
+	}()
+}
+
+// PublishToSubscribers delivers the event about the user to webhooks of its subscribers in background,
+// so data of the user reaches only those who already get reminders about them
+func (s *webhookService) PublishToSubscribers(ctx context.Context, event string, data any, userId string) {
+	ctx = context.WithoutCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.publishToSubscribers(ctx, event, data, userId)
+	}()
+}
+
+func (s *webhookService) publishToSubscribers(ctx context.Context, event string, data any, userId string) {
+	webhooks, err := s.webhookRepository.FindSubscriberWebhooksForEvent(ctx, event, userId)
+	if err != nil {
+		s.logger.Error("failed to find webhooks for event", slog.String("error", err.Error()))
+		return
+	}
+	s.publish(ctx, event, data, webhooks)
+}
+
+func (s *webhookService) publish(ctx context.Context, event string, data any, webhooks []model.Webhook) {
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(model.WebhookEvent{
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		s.logger.Error("failed to encode webhook payload", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, webhook := range webhooks {
+		delivery, err := s.webhookRepository.CreateDelivery(
+			ctx, webhook.Id, event, payload, nil, time.Now().Add(deliveryLease),
+		)
+		if err != nil {
+			s.logger.Error("error during saving webhook delivery", slog.String("error", err.Error()))
+			continue
+		}
+		s.dispatch(ctx, webhook, delivery)
+	}
+}
+
+func (s *webhookService) PublishBirthdays(ctx context.Context) error {
+	tx, err := s.webhookRepository.GetLock(ctx)
+	if errors.Is(err, repository.ErrLockTaken) {
+		s.logger.Info("birthday events are already published by other worker")
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("failed to take lock", slog.String("error", err.Error()))
+		return err
+	}
+	defer func() {
+		err := tx.Commit()
+		if err != nil {
+			s.logger.Error("error committing transaction", slog.String("error", err.Error()))
+		}
+	}()
+
+	birthdays, err := s.webhookRepository.FindBirthdays(ctx, tx, s.upcomingDays)
+	if err != nil {
+		s.logger.Error("failed to retrieve birthdays", slog.String("error", err.Error()))
+		return err
+	}
+
+	for _, birthday := range birthdays {
+		event := model.EventBirthdayUpcoming
+		if birthday.DaysUntilBirthday == 0 {
+			event = model.EventBirthdayToday
+		}
+		s.publishToSubscribers(ctx, event, birthday, birthday.UserId)
+	}
+	return nil
+}
+
+// RedeliverPending resumes deliveries left pending, e.g. when the process retrying them was restarted
+func (s *webhookService) RedeliverPending(ctx context.Context) error {
+	for {
+		deliveries, err := s.webhookRepository.ClaimDueDeliveries(
+			ctx, time.Now().Add(deliveryLease), redeliveryBatchSize,
+		)
+		if err != nil {
+			s.logger.Error("failed to claim pending webhook deliveries", slog.String("error", err.Error()))
+			return err
+		}
+		for _, delivery := range deliveries {
+			webhook, err := s.webhookRepository.FindWebhook(ctx, delivery.WebhookId)
+			if err != nil {
+				s.logger.Error("failed to find webhook", slog.String("error", err.Error()))
+				continue
+			}
+			s.dispatch(ctx, webhook, delivery)
+		}
+		if len(deliveries) < redeliveryBatchSize {
+			return nil
+		}
+	}
+}
+
+// Wait blocks until every delivery started by the service is finished.
+func (s *webhookService) Wait() {
+	s.wg.Wait()
+}
+
+func (s *webhookService) dispatch(ctx context.Context, webhookConfig model.Webhook, delivery model.WebhookDelivery) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		request := webhook.Request{
+			Url:        webhookConfig.Url,
+			Secret:     webhookConfig.Secret,
+			Event:      delivery.Event,
+			DeliveryId: delivery.Id,
+			Payload:    delivery.Payload,
+		}
+
+		for {
+			delivery.Attempts++
+			statusCode, err := s.sender.Send(ctx, request)
+			if statusCode != 0 {
+				delivery.ResponseStatus = &statusCode
+			}
+			delivery.Error = ""
+			if err != nil {
+				delivery.Error = err.Error()
+			}
+
+			// exponential backoff: base, base*2, base*4, ...
+			waitBeforeRetry := s.retryBackoff * time.Duration(1<<(delivery.Attempts-1))
+			delivery.NextAttemptAt = nil
+			switch {
+			case err == nil:
+				delivery.Status = model.WebhookDeliveryStatusSucceeded
+			case delivery.Attempts >= s.maxAttempts:
+				s.logger.Warn(
+					"max attempts reached, webhook was not delivered",
+					slog.String("delivery_id", delivery.Id),
+				)
+				delivery.Status = model.WebhookDeliveryStatusFailed
+			default:
+				delivery.Status = model.WebhookDeliveryStatusPending
+				nextAttemptAt := time.Now().Add(waitBeforeRetry)
+				delivery.NextAttemptAt = &nextAttemptAt
+			}
+			if err := s.webhookRepository.UpdateDelivery(ctx, delivery); err != nil {
+				s.logger.Error("failed to update webhook delivery", slog.String("error", err.Error()))
+			}
+			if delivery.Status != model.WebhookDeliveryStatusPending {
+				return
+			}
+
+			// delivery is stored as pending, so the worker resumes it if this process stops before the retry
+			select {
+			case <-time.After(waitBeforeRetry):
+			case <-ctx.Done():
+				s.logger.Warn(
+					"execution context was closed, delivery left pending",
+					slog.String("delivery_id", delivery.Id),
+				)
+				return
+			}
+			err = s.webhookRepository.ClaimDelivery(ctx, delivery.Id, time.Now().Add(deliveryLease))
+			if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+				// delivery was already picked up by another process
+				return
+			}
+			if err != nil {
+				s.logger.Error("failed to claim webhook delivery", slog.String("error", err.Error()))
+				return
+			}
+		}
+	}()
+}
@@ -4,25 +4,75 @@ import (
 	"log/slog"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/vshevchenk0/bday-notifier/internal/channel"
+	emailChannel "github.com/vshevchenk0/bday-notifier/internal/channel/email"
+	execHookChannel "github.com/vshevchenk0/bday-notifier/internal/channel/exechook"
+	smsChannel "github.com/vshevchenk0/bday-notifier/internal/channel/sms"
+	telegramChannel "github.com/vshevchenk0/bday-notifier/internal/channel/telegram"
+	webPushChannel "github.com/vshevchenk0/bday-notifier/internal/channel/webpush"
 	"github.com/vshevchenk0/bday-notifier/internal/config"
 	"github.com/vshevchenk0/bday-notifier/internal/repository"
+	channelRepository "github.com/vshevchenk0/bday-notifier/internal/repository/channel"
+	chatWebhookRepository "github.com/vshevchenk0/bday-notifier/internal/repository/chatwebhook"
+	inboxRepository "github.com/vshevchenk0/bday-notifier/internal/repository/inbox"
 	notificationRepository "github.com/vshevchenk0/bday-notifier/internal/repository/notification"
+	pushRepository "github.com/vshevchenk0/bday-notifier/internal/repository/push"
+	reminderRepository "github.com/vshevchenk0/bday-notifier/internal/repository/reminder"
+	settingsRepository "github.com/vshevchenk0/bday-notifier/internal/repository/settings"
+	smsRepository "github.com/vshevchenk0/bday-notifier/internal/repository/sms"
+	subscriptionRepository "github.com/vshevchenk0/bday-notifier/internal/repository/subscription"
+	userRepository "github.com/vshevchenk0/bday-notifier/internal/repository/user"
+	webhookRepository "github.com/vshevchenk0/bday-notifier/internal/repository/webhook"
 	"github.com/vshevchenk0/bday-notifier/internal/service"
+	accountService "github.com/vshevchenk0/bday-notifier/internal/service/account"
+	announcementService "github.com/vshevchenk0/bday-notifier/internal/service/announcement"
+	inboxService "github.com/vshevchenk0/bday-notifier/internal/service/inbox"
 	notificationService "github.com/vshevchenk0/bday-notifier/internal/service/notification"
+	webhookService "github.com/vshevchenk0/bday-notifier/internal/service/webhook"
+	"github.com/vshevchenk0/bday-notifier/pkg/chatwebhook"
+	"github.com/vshevchenk0/bday-notifier/pkg/exechook"
+	"github.com/vshevchenk0/bday-notifier/pkg/linktoken"
 	"github.com/vshevchenk0/bday-notifier/pkg/logger"
 	"github.com/vshevchenk0/bday-notifier/pkg/mailer"
+	"github.com/vshevchenk0/bday-notifier/pkg/sms"
+	"github.com/vshevchenk0/bday-notifier/pkg/telegram"
+	"github.com/vshevchenk0/bday-notifier/pkg/webhook"
+	"github.com/vshevchenk0/bday-notifier/pkg/webpush"
 )
 
 type serviceProvider struct {
 	config   *config.Config
 	database *sqlx.DB
 
-	mailer mailer.Mailer
-	logger *slog.Logger
+	mailer            mailer.Mailer
+	telegramClient    telegram.Client
+	chatWebhookClient chatwebhook.Client
+	webhookSender     webhook.Sender
+	smsGateway        sms.Gateway
+	webPushClient     webpush.Client
+	execHookRunner    exechook.Runner
+	linkManager       linktoken.Manager
+	logger            *slog.Logger
+
+	channels []channel.Channel
 
 	notificationRepository repository.NotificationRepository
+	chatWebhookRepository  repository.ChatWebhookRepository
+	webhookRepository      repository.WebhookRepository
+	smsRepository          repository.SmsRepository
+	pushRepository         repository.PushRepository
+	inboxRepository        repository.InboxRepository
+	reminderRepository     repository.ReminderRepository
+	userRepository         repository.UserRepository
+	subscriptionRepository repository.SubscriptionRepository
+	channelRepository      repository.ChannelRepository
+	settingsRepository     repository.SettingsRepository
 
 	notificationService service.NotificationService
+	announcementService service.AnnouncementService
+	webhookService      service.WebhookService
+	inboxService        service.InboxService
+	accountService      service.AccountService
 }
 
 func newServiceProvider(config *config.Config, db *sqlx.DB) *serviceProvider {
@@ -62,6 +112,105 @@ func (s *serviceProvider) Mailer() mailer.Mailer {
 	return s.mailer
 }
 
+func (s *serviceProvider) TelegramClient() telegram.Client {
+	if s.telegramClient == nil {
+		clientConfig := &telegram.ClientConfig{
+			Token:   s.Config().TelegramBotToken,
+			BaseUrl: s.Config().TelegramApiUrl,
+			Timeout: s.Config().TelegramRequestTimeout,
+		}
+		s.telegramClient = telegram.NewClient(clientConfig)
+	}
+	return s.telegramClient
+}
+
+func (s *serviceProvider) ChatWebhookClient() chatwebhook.Client {
+	if s.chatWebhookClient == nil {
+		clientConfig := &chatwebhook.ClientConfig{
+			Timeout:               s.Config().ChatWebhookRequestTimeout,
+			AllowPrivateAddresses: s.Config().ChatWebhookAllowPrivate,
+		}
+		s.chatWebhookClient = chatwebhook.NewClient(clientConfig)
+	}
+	return s.chatWebhookClient
+}
+
+func (s *serviceProvider) WebhookSender() webhook.Sender {
+	if s.webhookSender == nil {
+		senderConfig := &webhook.SenderConfig{
+			Timeout:               s.Config().WebhookRequestTimeout,
+			AllowPrivateAddresses: s.Config().WebhookAllowPrivate,
+		}
+		s.webhookSender = webhook.NewSender(senderConfig)
+	}
+	return s.webhookSender
+}
+
+func (s *serviceProvider) SmsGateway() sms.Gateway {
+	if s.smsGateway == nil {
+		gatewayConfig := &sms.GatewayConfig{
+			Url:             s.Config().SmsGatewayUrl,
+			AuthHeaderName:  s.Config().SmsGatewayAuthHeaderName,
+			AuthHeaderValue: s.Config().SmsGatewayAuthHeaderValue,
+			PayloadFormat:   s.Config().SmsGatewayPayloadFormat,
+			PayloadTemplate: s.Config().SmsGatewayPayloadTemplate,
+			Timeout:         s.Config().SmsGatewayRequestTimeout,
+		}
+		gateway, err := sms.NewGateway(gatewayConfig)
+		if err != nil {
+			panic("failed to init sms gateway")
+		}
+		s.smsGateway = gateway
+	}
+	return s.smsGateway
+}
+
+func (s *serviceProvider) WebPushClient() webpush.Client {
+	if s.webPushClient == nil {
+		clientConfig := &webpush.ClientConfig{
+			VapidPublicKey:  s.Config().VapidPublicKey,
+			VapidPrivateKey: s.Config().VapidPrivateKey,
+			Subject:         s.Config().VapidSubject,
+			Ttl:             s.Config().WebPushTtl,
+			Timeout:         s.Config().WebPushRequestTimeout,
+		}
+		client, err := webpush.NewClient(clientConfig)
+		if err != nil {
+			panic("failed to init web push client")
+		}
+		s.webPushClient = client
+	}
+	return s.webPushClient
+}
+
+func (s *serviceProvider) ExecHookRunner() exechook.Runner {
+	if s.execHookRunner == nil {
+		runnerConfig := &exechook.RunnerConfig{
+			Path:        s.Config().ExecHookPath,
+			Args:        s.Config().ExecHookArgs,
+			Timeout:     s.Config().ExecHookTimeout,
+			Concurrency: s.Config().ExecHookConcurrency,
+		}
+		s.execHookRunner = exechook.NewRunner(runnerConfig)
+	}
+	return s.execHookRunner
+}
+
+func (s *serviceProvider) LinkManager() linktoken.Manager {
+	if s.linkManager == nil {
+		managerConfig := &linktoken.ManagerConfig{
+			SigningKey: s.Config().LinkSigningKey,
+			TokenTtl:   s.Config().LinkTokenTtl,
+		}
+		linkManager, err := linktoken.NewManager(managerConfig)
+		if err != nil {
+			panic("failed to init link token manager")
+		}
+		s.linkManager = linkManager
+	}
+	return s.linkManager
+}
+
 func (s *serviceProvider) Logger() *slog.Logger {
 	if s.logger == nil {
 		logger := logger.NewLogger(s.Config().Env)
@@ -70,6 +219,37 @@ func (s *serviceProvider) Logger() *slog.Logger {
 	return s.logger
 }
 
+func (s *serviceProvider) Channels() []channel.Channel {
+	if s.channels == nil {
+		s.channels = []channel.Channel{
+			emailChannel.NewChannel(s.Mailer()),
+		}
+		if s.Config().TelegramBotToken != "" {
+			s.channels = append(s.channels, telegramChannel.NewChannel(s.TelegramClient()))
+		}
+		if s.Config().SmsGatewayUrl != "" {
+			channelConfig := &smsChannel.SmsChannelConfig{
+				MaxParts:       s.Config().SmsMaxParts,
+				RecipientLimit: s.Config().SmsDailyLimitPerRecipient,
+				TotalLimit:     s.Config().SmsDailyLimit,
+			}
+			s.channels = append(s.channels, smsChannel.NewChannel(s.SmsGateway(), s.SmsRepository(), channelConfig))
+		}
+		if s.Config().VapidPrivateKey != "" {
+			s.channels = append(s.channels, webPushChannel.NewChannel(s.WebPushClient(), s.PushRepository()))
+		}
+		if s.Config().ExecHookPath != "" {
+			channelConfig := &execHookChannel.ExecHookChannelConfig{
+				MaxAttempts:    s.Config().ExecHookMaxAttempts,
+				RetryBackoff:   s.Config().ExecHookRetryBackoff,
+				RetryExitCodes: s.Config().ExecHookRetryExitCodes,
+			}
+			s.channels = append(s.channels, execHookChannel.NewChannel(s.ExecHookRunner(), channelConfig, s.Logger()))
+		}
+	}
+	return s.channels
+}
+
 func (s *serviceProvider) NotificationRepository() repository.NotificationRepository {
 	if s.notificationRepository == nil {
 		s.notificationRepository = notificationRepository.NewRepository(s.Database())
@@ -77,13 +257,153 @@ func (s *serviceProvider) NotificationRepository() repository.NotificationReposi
 	return s.notificationRepository
 }
 
+func (s *serviceProvider) ChatWebhookRepository() repository.ChatWebhookRepository {
+	if s.chatWebhookRepository == nil {
+		s.chatWebhookRepository = chatWebhookRepository.NewRepository(s.Database())
+	}
+	return s.chatWebhookRepository
+}
+
+func (s *serviceProvider) WebhookRepository() repository.WebhookRepository {
+	if s.webhookRepository == nil {
+		s.webhookRepository = webhookRepository.NewRepository(s.Database())
+	}
+	return s.webhookRepository
+}
+
+func (s *serviceProvider) SmsRepository() repository.SmsRepository {
+	if s.smsRepository == nil {
+		s.smsRepository = smsRepository.NewRepository(s.Database())
+	}
+	return s.smsRepository
+}
+
+func (s *serviceProvider) PushRepository() repository.PushRepository {
+	if s.pushRepository == nil {
+		s.pushRepository = pushRepository.NewRepository(s.Database())
+	}
+	return s.pushRepository
+}
+
+func (s *serviceProvider) InboxRepository() repository.InboxRepository {
+	if s.inboxRepository == nil {
+		s.inboxRepository = inboxRepository.NewRepository(s.Database())
+	}
+	return s.inboxRepository
+}
+
+func (s *serviceProvider) ReminderRepository() repository.ReminderRepository {
+	if s.reminderRepository == nil {
+		s.reminderRepository = reminderRepository.NewRepository(s.Database())
+	}
+	return s.reminderRepository
+}
+
+func (s *serviceProvider) UserRepository() repository.UserRepository {
+	if s.userRepository == nil {
+		s.userRepository = userRepository.NewRepository(s.Database())
+	}
+	return s.userRepository
+}
+
+func (s *serviceProvider) SubscriptionRepository() repository.SubscriptionRepository {
+	if s.subscriptionRepository == nil {
+		s.subscriptionRepository = subscriptionRepository.NewRepository(s.Database())
+	}
+	return s.subscriptionRepository
+}
+
+func (s *serviceProvider) ChannelRepository() repository.ChannelRepository {
+	if s.channelRepository == nil {
+		s.channelRepository = channelRepository.NewRepository(s.Database())
+	}
+	return s.channelRepository
+}
+
+func (s *serviceProvider) SettingsRepository() repository.SettingsRepository {
+	if s.settingsRepository == nil {
+		s.settingsRepository = settingsRepository.NewRepository(s.Database())
+	}
+	return s.settingsRepository
+}
+
 func (s *serviceProvider) NotificationService() service.NotificationService {
 	if s.notificationService == nil {
+		serviceConfig := &notificationService.NotificationServiceConfig{
+			Concurrency: s.Config().NotificationConcurrency,
+		}
+		// links cannot be signed without the key, so reminders are sent without them
+		var linkManager linktoken.Manager
+		if s.Config().LinkSigningKey != "" {
+			serviceConfig.PublicUrl = s.Config().AppPublicUrl
+			linkManager = s.LinkManager()
+		}
 		s.notificationService = notificationService.NewNotificationService(
 			s.NotificationRepository(),
-			s.Mailer(),
+			s.InboxRepository(),
+			s.Channels(),
+			linkManager,
+			serviceConfig,
 			s.Logger(),
 		)
 	}
 	return s.notificationService
 }
+
+func (s *serviceProvider) AnnouncementService() service.AnnouncementService {
+	if s.announcementService == nil {
+		s.announcementService = announcementService.NewAnnouncementService(
+			s.ChatWebhookRepository(),
+			s.ChatWebhookClient(),
+			s.Logger(),
+		)
+	}
+	return s.announcementService
+}
+
+func (s *serviceProvider) WebhookService() service.WebhookService {
+	if s.webhookService == nil {
+		serviceConfig := &webhookService.WebhookServiceConfig{
+			MaxAttempts:  s.Config().WebhookMaxAttempts,
+			RetryBackoff: s.Config().WebhookRetryBackoff,
+			UpcomingDays: s.Config().WebhookUpcomingDays,
+		}
+		s.webhookService = webhookService.NewWebhookService(
+			s.WebhookRepository(),
+			s.WebhookSender(),
+			serviceConfig,
+			s.Logger(),
+		)
+	}
+	return s.webhookService
+}
+
+func (s *serviceProvider) InboxService() service.InboxService {
+	if s.inboxService == nil {
+		s.inboxService = inboxService.NewInboxService(
+			s.InboxRepository(),
+			s.Config().InboxRetention,
+			s.Logger(),
+		)
+	}
+	return s.inboxService
+}
+
+func (s *serviceProvider) AccountService() service.AccountService {
+	if s.accountService == nil {
+		s.accountService = accountService.NewAccountService(
+			s.UserRepository(),
+			s.SubscriptionRepository(),
+			s.ChannelRepository(),
+			s.SettingsRepository(),
+			s.InboxRepository(),
+			s.ReminderRepository(),
+			s.WebhookRepository(),
+			s.ChatWebhookRepository(),
+			s.PushRepository(),
+			s.Config().AccountDeletionGracePeriod,
+			s.Logger(),
+		)
+	}
+	return s.accountService
+}
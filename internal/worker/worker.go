@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -27,15 +28,37 @@ func (w *Worker) initServiceProvider(config *config.Config, db *sqlx.DB) error {
 	return nil
 }
 
+// Run executes every job of the day, failure of one job does not prevent the others from running
 func (w *Worker) Run(ctx context.Context, doneChan chan struct{}) error {
 	currentTime := time.Now()
 	timeUntilNextDay := time.Until(currentTime.Add(time.Hour * time.Duration(24-currentTime.Hour())).Round(time.Hour))
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeUntilNextDay)
 	defer cancel()
-	if err := w.serviceProdider.NotificationService().NotifyUsers(timeoutCtx); err != nil {
-		w.serviceProdider.Logger().Error("worker error", slog.String("error", err.Error()))
-		return err
+	defer func() {
+		// webhooks are delivered in background, worker should not exit before they are done
+		w.serviceProdider.WebhookService().Wait()
+		doneChan <- struct{}{}
+	}()
+
+	jobs := []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{"notify users", w.serviceProdider.NotificationService().NotifyUsers},
+		{"announce birthdays", w.serviceProdider.AnnouncementService().Announce},
+		{"publish birthdays", w.serviceProdider.WebhookService().PublishBirthdays},
+		{"redeliver pending webhooks", w.serviceProdider.WebhookService().RedeliverPending},
+		{"delete expired inbox notifications", w.serviceProdider.InboxService().DeleteExpired},
+		{"delete scheduled accounts", w.serviceProdider.AccountService().DeleteScheduled},
 	}
-	doneChan <- struct{}{}
-	return nil
+	var errs []error
+	for _, job := range jobs {
+		if err := job.run(timeoutCtx); err != nil {
+			w.serviceProdider.Logger().Error(
+				"worker error", slog.String("job", job.name), slog.String("error", err.Error()),
+			)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
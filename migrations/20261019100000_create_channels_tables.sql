@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_channels (
+	user_id uuid references users (id) on delete cascade,
+	channel varchar(64) not null,
+	address varchar(1024) not null,
+	enabled boolean not null default true,
+	unique (user_id, channel)
+);
+
+CREATE TABLE subscription_channels (
+	user_id uuid not null,
+	subscriber_id uuid not null,
+	channel varchar(64) not null,
+	address varchar(1024),
+	enabled boolean not null default true,
+	foreign key (user_id, subscriber_id) references subscriptions (user_id, subscriber_id) on delete cascade,
+	unique (user_id, subscriber_id, channel)
+);
+
+CREATE TABLE notification_deliveries (
+	id uuid primary key default gen_random_uuid(),
+	birthday_user_id uuid references users (id) on delete cascade,
+	subscriber_id uuid references users (id) on delete cascade,
+	channel varchar(64) not null,
+	address varchar(1024) not null,
+	status varchar(16) not null,
+	error text not null default '',
+	created_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_deliveries;
+DROP TABLE subscription_channels;
+DROP TABLE user_channels;
+-- +goose StatementEnd
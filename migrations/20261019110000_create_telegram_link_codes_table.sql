@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE telegram_link_codes (
+	code varchar(32) primary key,
+	user_id uuid not null references users (id) on delete cascade,
+	expires_at timestamptz not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE telegram_link_codes;
+-- +goose StatementEnd
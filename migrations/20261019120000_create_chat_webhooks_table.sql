@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN chat_handle varchar(255);
+
+CREATE TABLE chat_webhooks (
+	id uuid primary key default gen_random_uuid(),
+	owner_id uuid not null references users (id) on delete cascade,
+	name varchar(255) not null,
+	url varchar(1024) not null,
+	format varchar(16) not null default 'slack',
+	announce_today boolean not null default true,
+	remind_before_days integer not null default 0,
+	enabled boolean not null default true
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chat_webhooks;
+ALTER TABLE users DROP COLUMN chat_handle;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhooks (
+	id uuid primary key default gen_random_uuid(),
+	owner_id uuid not null references users (id) on delete cascade,
+	url varchar(1024) not null,
+	secret varchar(255) not null,
+	events text[] not null,
+	enabled boolean not null default true,
+	created_at timestamptz not null default now()
+);
+
+CREATE TABLE webhook_deliveries (
+	id uuid primary key default gen_random_uuid(),
+	webhook_id uuid not null references webhooks (id) on delete cascade,
+	redelivery_of uuid references webhook_deliveries (id) on delete set null,
+	event varchar(64) not null,
+	payload jsonb not null,
+	status varchar(16) not null default 'pending',
+	attempts integer not null default 0,
+	response_status integer,
+	error text not null default '',
+	created_at timestamptz not null default now(),
+	updated_at timestamptz not null default now()
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries (webhook_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN phone varchar(16);
+
+CREATE TABLE sms_daily_usage (
+	day date not null default CURRENT_DATE,
+	phone varchar(16) not null,
+	count integer not null default 0,
+	primary key (day, phone)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sms_daily_usage;
+ALTER TABLE users DROP COLUMN phone;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE push_subscriptions (
+	id uuid primary key default gen_random_uuid(),
+	user_id uuid not null references users (id) on delete cascade,
+	endpoint varchar(2048) not null unique,
+	p256dh varchar(255) not null,
+	auth varchar(255) not null,
+	created_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE push_subscriptions;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE inbox_notifications (
+	id uuid primary key default gen_random_uuid(),
+	user_id uuid not null references users (id) on delete cascade,
+	birthday_user_id uuid not null references users (id) on delete cascade,
+	subject varchar(255) not null,
+	body text not null,
+	read_at timestamptz,
+	created_at timestamptz not null default now()
+);
+CREATE INDEX inbox_notifications_user_id_created_at_idx ON inbox_notifications (user_id, created_at DESC, id DESC);
+CREATE INDEX inbox_notifications_unread_idx ON inbox_notifications (user_id) WHERE read_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE inbox_notifications;
+-- +goose StatementEnd
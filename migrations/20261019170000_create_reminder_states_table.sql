@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reminder_states (
+	user_id uuid not null,
+	subscriber_id uuid not null,
+	occurrence date not null,
+	snoozed_until date,
+	congratulated_at timestamptz,
+	primary key (user_id, subscriber_id, occurrence),
+	foreign key (user_id, subscriber_id) references subscriptions (user_id, subscriber_id) on delete cascade
+);
+CREATE INDEX reminder_states_snoozed_until_idx ON reminder_states (snoozed_until);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reminder_states;
+-- +goose StatementEnd
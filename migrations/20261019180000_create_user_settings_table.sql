@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_settings (
+	user_id uuid primary key references users (id) on delete cascade,
+	vacation_from date,
+	vacation_until date,
+	vacation_delegate_id uuid references users (id) on delete set null,
+	check (vacation_from <= vacation_until),
+	check (vacation_delegate_id != user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_settings;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN department text;
+ALTER TABLE users ADD COLUMN office text;
+CREATE TABLE subscription_rules (
+	subscriber_id uuid not null references users (id) on delete cascade,
+	rule text not null check (rule in ('everyone', 'department', 'office')),
+	notify_before_days integer not null,
+	created_at timestamptz not null default now(),
+	primary key (subscriber_id, rule)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE subscription_rules;
+ALTER TABLE users DROP COLUMN office;
+ALTER TABLE users DROP COLUMN department;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_settings ADD COLUMN hide_subscriptions boolean not null default false;
+ALTER TABLE user_settings ADD COLUMN notify_new_subscribers boolean not null default false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_settings DROP COLUMN notify_new_subscribers;
+ALTER TABLE user_settings DROP COLUMN hide_subscriptions;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_settings ADD COLUMN hide_age boolean not null default false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_settings DROP COLUMN hide_age;
+-- +goose StatementEnd
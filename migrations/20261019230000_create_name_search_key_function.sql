@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- name_search_key reduces a name to a latin skeleton, so cyrillic names and their different
+-- transliterations get the same key, e.g. both Шевченко and Shevchenko become shevchenko
+CREATE FUNCTION name_search_key(value text) RETURNS text
+LANGUAGE sql IMMUTABLE STRICT AS $$
+	SELECT regexp_replace(regexp_replace(
+		translate(replace(replace(
+			translate(replace(replace(replace(replace(replace(replace(
+				translate(
+					replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(
+						lower(value),
+					'щ', 'shch'), 'ш', 'sh'), 'ч', 'ch'), 'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'),
+					'ю', 'yu'), 'я', 'ya'), 'ё', 'yo'), 'є', 'ye'), 'ї', 'yi'),
+					'абвгґдезиійклмнопрстуфыэъь', 'abvggdeziiyklmnoprstufye'
+				),
+			'shch', 'sch'), 'kh', 'h'), 'ts', 'c'), 'tz', 'c'), 'ph', 'f'), 'ck', 'k'),
+			'wqj', 'vky'),
+		'x', 'ks'), 'ye', 'e'),
+		'y', 'i'),
+	'[^a-z0-9]', '', 'g'), '(.)\1+', '\1', 'g');
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION name_search_key(text);
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN consented_at timestamptz;
+ALTER TABLE users ADD COLUMN deletion_requested_at timestamptz;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN deletion_requested_at;
+ALTER TABLE users DROP COLUMN consented_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN email_verified_at timestamptz;
+ALTER TABLE users ADD COLUMN verification_sent_at timestamptz;
+-- users registered before verification was introduced keep getting reminders
+UPDATE users SET email_verified_at = now();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN verification_sent_at;
+ALTER TABLE users DROP COLUMN email_verified_at;
+-- +goose StatementEnd
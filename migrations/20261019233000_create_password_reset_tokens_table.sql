@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE password_reset_tokens (
+	token_hash varchar(64) primary key,
+	user_id uuid not null references users (id) on delete cascade,
+	expires_at timestamptz not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE password_reset_tokens;
+-- +goose StatementEnd
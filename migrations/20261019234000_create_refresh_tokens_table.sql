@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens (
+	id uuid primary key default gen_random_uuid(),
+	user_id uuid not null references users (id) on delete cascade,
+	session_id uuid not null,
+	token_hash varchar(64) not null unique,
+	expires_at timestamptz not null,
+	rotated_at timestamptz,
+	revoked_at timestamptz,
+	created_at timestamptz not null default now()
+);
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE refresh_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries ADD COLUMN next_attempt_at timestamptz;
+UPDATE webhook_deliveries SET next_attempt_at = now() WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_next_attempt_at_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries DROP COLUMN next_attempt_at;
+-- +goose StatementEnd
@@ -0,0 +1,83 @@
+package chatwebhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+var ErrInvalidUrl = errors.New("chat webhook url must be an absolute http or https url")
+
+type ClientConfig struct {
+	Timeout time.Duration
+	// AllowPrivateAddresses allows webhooks to internal addresses, e.g. of a self-hosted Mattermost
+	AllowPrivateAddresses bool
+}
+
+// Client posts messages to Slack or Mattermost incoming webhooks.
+// Both of them accept the same payload with markdown formatted text.
+type Client interface {
+	Post(ctx context.Context, webhookUrl, text string) error
+	ValidateUrl(ctx context.Context, webhookUrl string) error
+}
+
+type payload struct {
+	Text string `json:"text"`
+}
+
+type client struct {
+	httpClient            *http.Client
+	allowPrivateAddresses bool
+}
+
+func NewClient(config *ClientConfig) *client {
+	transport := httpclient.NewTransport(config.Timeout, config.AllowPrivateAddresses)
+	return &client{
+		httpClient:            &http.Client{Timeout: config.Timeout, Transport: transport},
+		allowPrivateAddresses: config.AllowPrivateAddresses,
+	}
+}
+
+// ValidateUrl checks that the url can be used to post messages to,
+// addresses are checked again on every request, since the host may resolve differently later
+func (c *client) ValidateUrl(ctx context.Context, webhookUrl string) error {
+	u, err := url.Parse(webhookUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidUrl
+	}
+	if c.allowPrivateAddresses {
+		return nil
+	}
+	return httpclient.CheckPublicHost(ctx, u.Hostname())
+}
+
+func (c *client) Post(ctx context.Context, webhookUrl, text string) error {
+	body, err := json.Marshal(payload{Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to chat webhook: %w", httpclient.StripUrl(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody := httpclient.ReadErrorBody(resp)
+		return fmt.Errorf("chat webhook responded with status %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
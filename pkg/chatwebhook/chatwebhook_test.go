@@ -0,0 +1,114 @@
+package chatwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+func TestPost(t *testing.T) {
+	var got payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type = %s, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c := NewClient(&ClientConfig{Timeout: time.Second, AllowPrivateAddresses: true})
+	if err := c.Post(context.Background(), server.URL+"/hooks/abc", "*Today* is John's birthday"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Text != "*Today* is John's birthday" {
+		t.Errorf("text = %q, want the posted text", got.Text)
+	}
+}
+
+func TestPostErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no_service" + strings.Repeat("x", 1024)))
+	}))
+	defer server.Close()
+
+	c := NewClient(&ClientConfig{Timeout: time.Second, AllowPrivateAddresses: true})
+	err := c.Post(context.Background(), server.URL, "text")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no_service") {
+		t.Errorf("error = %q, want status and beginning of the response body", err)
+	}
+	if len(err.Error()) > 512 {
+		t.Errorf("error length = %d, want the response body to be truncated", len(err.Error()))
+	}
+}
+
+func TestPostHidesUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	webhookUrl := server.URL + "/hooks/secret-key"
+	c := NewClient(&ClientConfig{Timeout: time.Second, AllowPrivateAddresses: true})
+	err := c.Post(context.Background(), webhookUrl, "text")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("error %q contains webhook url", err)
+	}
+}
+
+func TestPostRefusesPrivateAddress(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	c := NewClient(&ClientConfig{Timeout: time.Second})
+	err := c.Post(context.Background(), server.URL, "text")
+	if !errors.Is(err, httpclient.ErrForbiddenAddress) {
+		t.Errorf("error = %v, want ErrForbiddenAddress", err)
+	}
+	if requested {
+		t.Error("request reached the loopback server")
+	}
+}
+
+func TestValidateUrl(t *testing.T) {
+	c := NewClient(&ClientConfig{Timeout: time.Second})
+	tests := []struct {
+		url     string
+		wantErr error
+	}{
+		{"ftp://hooks.example.com/abc", ErrInvalidUrl},
+		{"/hooks/abc", ErrInvalidUrl},
+		{"http://127.0.0.1:8080/hooks/abc", httpclient.ErrForbiddenAddress},
+		{"http://169.254.169.254/latest/meta-data", httpclient.ErrForbiddenAddress},
+		{"https://[::1]/hooks/abc", httpclient.ErrForbiddenAddress},
+		{"https://93.184.215.14/hooks/abc", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			err := c.ValidateUrl(context.Background(), test.url)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
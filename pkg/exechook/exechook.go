@@ -0,0 +1,133 @@
+package exechook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	// time given to the process to close its output after it was killed
+	waitDelay = time.Second
+	// default amount of stdout and stderr kept in the result
+	defaultMaxOutputLength = 4096
+)
+
+// ErrTimeout is returned when the process did not finish in time and was killed.
+var ErrTimeout = errors.New("exec hook timed out")
+
+type RunnerConfig struct {
+	Path            string
+	Args            []string
+	Timeout         time.Duration
+	Concurrency     int
+	MaxOutputLength int
+}
+
+type Result struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+type Runner interface {
+	Run(ctx context.Context, stdin []byte, env []string) (Result, error)
+}
+
+type runner struct {
+	path            string
+	args            []string
+	timeout         time.Duration
+	semaphore       chan struct{}
+	maxOutputLength int
+}
+
+func NewRunner(config *RunnerConfig) *runner {
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	maxOutputLength := config.MaxOutputLength
+	if maxOutputLength < 1 {
+		maxOutputLength = defaultMaxOutputLength
+	}
+	return &runner{
+		path:            config.Path,
+		args:            config.Args,
+		timeout:         config.Timeout,
+		semaphore:       make(chan struct{}, concurrency),
+		maxOutputLength: maxOutputLength,
+	}
+}
+
+// Run starts the executable with given stdin and environment variables, added to PATH of the
+// current process. Rest of the environment is not inherited, so the hook has no access to secrets.
+// Non-zero exit code is not an error, it is reported in the result.
+func (r *runner) Run(ctx context.Context, stdin []byte, env []string) (Result, error) {
+	select {
+	case r.semaphore <- struct{}{}:
+		defer func() { <-r.semaphore }()
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	runCtx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	stdout := &limitedBuffer{limit: r.maxOutputLength}
+	stderr := &limitedBuffer{limit: r.maxOutputLength}
+	cmd := exec.CommandContext(runCtx, r.path, r.args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH")}, env...)
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	result := Result{
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return result, ErrTimeout
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return result, fmt.Errorf("failed to run exec hook: %w", err)
+	}
+	return result, nil
+}
+
+// limitedBuffer keeps only the beginning of the output, but never fails the write,
+// so the process is not killed by a broken pipe because of verbose output
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if rest := b.limit - b.buf.Len(); rest > 0 {
+		if len(p) > rest {
+			b.buf.Write(p[:rest])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenAddress = errors.New("url must not point to a private or loopback address")
+
+// blocked networks which are not covered by net.IP methods: "this" network, carrier-grade nat,
+// ietf protocol assignments, benchmarking and reserved ones
+var blockedNetworks = mustParseNetworks("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4")
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isPublicIp reports whether the ip is reachable from the internet, so requests to it cannot hit internal services
+func isPublicIp(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIp(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// NewTransport returns transport for requests to user supplied urls, which refuses to connect
+// to private and loopback addresses unless allowPrivateAddresses is set.
+// address is checked after name resolution, so it also applies to redirects and dns rebinding.
+// proxy is not used, since the check would apply to the proxy address instead of the target
+func NewTransport(timeout time.Duration, allowPrivateAddresses bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateAddresses {
+		dialer := &net.Dialer{Timeout: timeout, Control: checkDialAddress}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+	return transport
+}
+
+// CheckPublicHost resolves the host and fails if any of its addresses is not public,
+// it lets user supplied urls be rejected when they are saved instead of on the first request
+func CheckPublicHost(ctx context.Context, host string) error {
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve host: %w", err)
+	}
+	for _, address := range addresses {
+		if !isPublicIp(address.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
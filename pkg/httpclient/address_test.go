@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIsPublicIp(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			if got := isPublicIp(net.ParseIP(test.ip)); got != test.public {
+				t.Errorf("isPublicIp(%s) = %v, want %v", test.ip, got, test.public)
+			}
+		})
+	}
+}
+
+func TestCheckPublicHost(t *testing.T) {
+	if err := CheckPublicHost(context.Background(), "127.0.0.1"); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("error = %v, want ErrForbiddenAddress", err)
+	}
+	if err := CheckPublicHost(context.Background(), "93.184.215.14"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maximum length of the response body included into the error
+const maxErrorBodyLength = 256
+
+// StripUrl removes the request url from the error of the http client.
+// urls of external services carry tokens and credentials, which must not end up in logs
+func StripUrl(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// ReadErrorBody returns the beginning of the body of unsuccessful response, to be included into the error
+func ReadErrorBody(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+	return string(body)
+}
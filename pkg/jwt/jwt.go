@@ -9,8 +9,14 @@ import (
 )
 
 type Manager interface {
-	NewToken(id string) (string, error)
-	ParseToken(tokenString string) (string, error)
+	NewToken(userId, tokenId string) (string, error)
+	ParseToken(tokenString string) (Claims, error)
+}
+
+// Claims are the verified claims of the token
+type Claims struct {
+	UserId  string
+	TokenId string
 }
 
 type ManagerConfig struct {
@@ -38,11 +44,15 @@ func NewManager(config *ManagerConfig) (*manager, error) {
 	}, nil
 }
 
-func (m *manager) NewToken(id string) (string, error) {
+// NewToken signs token of the user, token id is put into jti claim, so that the token can be revoked
+func (m *manager) NewToken(userId, tokenId string) (string, error) {
+	now := time.Now()
 	claims := CustomClaims{
-		Id: id,
+		Id: userId,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(m.tokenTtl).Unix(),
+			Id:        tokenId,
+			ExpiresAt: now.Add(m.tokenTtl).Unix(),
+			IssuedAt:  now.Unix(),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -50,19 +60,23 @@ func (m *manager) NewToken(id string) (string, error) {
 	return signedString, err
 }
 
-func (m *manager) ParseToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+func (m *manager) ParseToken(tokenString string) (Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(m.signingKey), nil
 	})
 	if err != nil {
-		return "", err
+		return Claims{}, err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", errors.New("error getting claims from token")
+	claims, ok := token.Claims.(*CustomClaims)
+	if !ok || claims.Id == "" {
+		return Claims{}, errors.New("error getting claims from token")
 	}
-	return claims["id"].(string), nil
+	// tokens issued before jti was added have empty token id
+	return Claims{
+		UserId:  claims.Id,
+		TokenId: claims.StandardClaims.Id,
+	}, nil
 }
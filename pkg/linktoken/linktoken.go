@@ -0,0 +1,75 @@
+package linktoken
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// ErrInvalidToken is returned when token is malformed, expired, has a wrong signature
+// or was issued for another action.
+var ErrInvalidToken = errors.New("invalid link token")
+
+// Manager issues tokens embedded into links sent to users, e.g. unsubscribe links in emails.
+// Every token is bound to an action, so a token of one link cannot be used for another.
+type Manager interface {
+	NewToken(action string, params map[string]string) (string, error)
+	ParseToken(action, tokenString string) (map[string]string, error)
+}
+
+type ManagerConfig struct {
+	SigningKey string
+	TokenTtl   time.Duration
+}
+
+type manager struct {
+	signingKey string
+	tokenTtl   time.Duration
+}
+
+type linkClaims struct {
+	Action string            `json:"act"`
+	Params map[string]string `json:"params"`
+	jwt.StandardClaims
+}
+
+func NewManager(config *ManagerConfig) (*manager, error) {
+	if config.SigningKey == "" {
+		return nil, errors.New("empty signing key")
+	}
+	return &manager{
+		signingKey: config.SigningKey,
+		tokenTtl:   config.TokenTtl,
+	}, nil
+}
+
+func (m *manager) NewToken(action string, params map[string]string) (string, error) {
+	claims := linkClaims{
+		Action: action,
+		Params: params,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(m.tokenTtl).Unix(),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.signingKey))
+}
+
+func (m *manager) ParseToken(action, tokenString string) (map[string]string, error) {
+	claims := &linkClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(m.signingKey), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Action != action {
+		return nil, ErrInvalidToken
+	}
+	return claims.Params, nil
+}
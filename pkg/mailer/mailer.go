@@ -6,7 +6,8 @@ import (
 	"log/slog"
 	"net"
 	"net/smtp"
-	"sync"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -21,7 +22,8 @@ type MailerConfig struct {
 }
 
 type Mailer interface {
-	Send(ctx context.Context, wg *sync.WaitGroup, addresses []string, subject, body string)
+	Send(ctx context.Context, addresses []string, subject, body string) error
+	SendWithHeaders(ctx context.Context, addresses []string, subject, body string, headers map[string]string) error
 }
 
 type mailer struct {
@@ -46,32 +48,48 @@ func NewMailer(config *MailerConfig, logger *slog.Logger) *mailer {
 	}
 }
 
-func (m *mailer) sendEmail(addresses []string, subject string, body string) error {
-	formattedSubject := fmt.Sprintf("Subject: %s\n", subject)
-	message := []byte(formattedSubject + body)
-	err := smtp.SendMail(m.smtpAddr, m.auth, m.email, addresses, message)
+func (m *mailer) sendEmail(addresses []string, subject, body string, headers map[string]string) error {
+	message := &strings.Builder{}
+	fmt.Fprintf(message, "Subject: %s\r\n", subject)
+	// headers are sorted to keep the message stable between retries
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(message, "%s: %s\r\n", name, headers[name])
+	}
+	message.WriteString("\r\n")
+	message.WriteString(body)
+	err := smtp.SendMail(m.smtpAddr, m.auth, m.email, addresses, []byte(message.String()))
 	return err
 }
 
-func (m *mailer) Send(ctx context.Context, wg *sync.WaitGroup, addresses []string, subject, body string) {
-	defer wg.Done()
+func (m *mailer) Send(ctx context.Context, addresses []string, subject, body string) error {
+	return m.SendWithHeaders(ctx, addresses, subject, body, nil)
+}
+
+func (m *mailer) SendWithHeaders(
+	ctx context.Context, addresses []string, subject, body string, headers map[string]string,
+) error {
 	queue := make(chan struct{}, 1)
 	defer close(queue)
 	queue <- struct{}{}
 	retriesCount := 0
 
 	for range queue {
-		err := m.sendEmail(addresses, subject, body)
+		err := m.sendEmail(addresses, subject, body, headers)
 		if err == nil {
 			m.logger.Info("successfully sent emails", slog.String("subject", subject))
-			return
+			return nil
 		}
 
 		// requeue send message job when error occurs
 		m.logger.Error("error sending email", slog.String("error", err.Error()))
 		if retriesCount >= m.maxRetriesCount {
 			m.logger.Warn("max retries reached, emails were not sent")
-			return
+			return err
 		}
 		retriesCount++
 
@@ -88,7 +106,8 @@ func (m *mailer) Send(ctx context.Context, wg *sync.WaitGroup, addresses []strin
 			queue <- struct{}{}
 		case <-ctx.Done():
 			m.logger.Warn("execution context was closed, exiting")
-			return
+			return ctx.Err()
 		}
 	}
+	return nil
 }
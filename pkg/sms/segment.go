@@ -0,0 +1,74 @@
+package sms
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// single message limits, in characters
+	gsm7SegmentLength = 160
+	ucs2SegmentLength = 70
+	ellipsis          = "..."
+)
+
+// basic GSM 03.38 alphabet, extension table characters are counted as regular ones
+const gsm7Alphabet = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà^{}\\[~]|€"
+
+// IsGSM7 reports whether text can be encoded with GSM 7-bit alphabet.
+// Otherwise the message is sent in UCS-2 and segments are shorter.
+func IsGSM7(text string) bool {
+	for _, r := range text {
+		if !strings.ContainsRune(gsm7Alphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SegmentLength returns how much characters of text fit into a single segment.
+func SegmentLength(text string) int {
+	if IsGSM7(text) {
+		return gsm7SegmentLength
+	}
+	return ucs2SegmentLength
+}
+
+// Truncate shortens text to a single segment, marking the cut with ellipsis.
+func Truncate(text string) string {
+	length := SegmentLength(text)
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:length-len(ellipsis)]) + ellipsis
+}
+
+// Split cuts text into at most maxParts single segment messages,
+// preferring to break on whitespace. The last part is truncated if text does not fit.
+func Split(text string, maxParts int) []string {
+	length := SegmentLength(text)
+	runes := []rune(strings.TrimSpace(text))
+	parts := make([]string, 0, maxParts)
+	for len(runes) > 0 && len(parts) < maxParts {
+		if len(runes) <= length {
+			parts = append(parts, string(runes))
+			return parts
+		}
+		if len(parts) == maxParts-1 {
+			parts = append(parts, Truncate(string(runes)))
+			return parts
+		}
+		cut := length
+		for i := length; i > length/2; i-- {
+			if runes[i] == ' ' || runes[i] == '\n' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	return parts
+}
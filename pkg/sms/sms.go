@@ -0,0 +1,114 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+const (
+	PayloadFormatJson = "json"
+	PayloadFormatForm = "form"
+
+	defaultJsonTemplate = `{"to":{{json .To}},"text":{{json .Text}}}`
+	defaultFormTemplate = `to={{urlquery .To}}&text={{urlquery .Text}}`
+)
+
+type GatewayConfig struct {
+	Url             string
+	AuthHeaderName  string
+	AuthHeaderValue string
+	PayloadFormat   string
+	PayloadTemplate string
+	Timeout         time.Duration
+}
+
+type Gateway interface {
+	Send(ctx context.Context, to, text string) error
+}
+
+type templateData struct {
+	To   string
+	Text string
+}
+
+type gateway struct {
+	url             string
+	authHeaderName  string
+	authHeaderValue string
+	contentType     string
+	payloadTemplate *template.Template
+	httpClient      *http.Client
+}
+
+func NewGateway(config *GatewayConfig) (*gateway, error) {
+	var contentType, payloadTemplate string
+	switch config.PayloadFormat {
+	case PayloadFormatJson:
+		contentType, payloadTemplate = "application/json", defaultJsonTemplate
+	case PayloadFormatForm:
+		contentType, payloadTemplate = "application/x-www-form-urlencoded", defaultFormTemplate
+	default:
+		return nil, fmt.Errorf("unknown payload format: %s", config.PayloadFormat)
+	}
+	if config.PayloadTemplate != "" {
+		payloadTemplate = config.PayloadTemplate
+	}
+
+	funcs := template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	tmpl, err := template.New("payload").Funcs(funcs).Parse(payloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+	if config.Url == "" {
+		return nil, errors.New("empty gateway url")
+	}
+
+	return &gateway{
+		url:             config.Url,
+		authHeaderName:  config.AuthHeaderName,
+		authHeaderValue: config.AuthHeaderValue,
+		contentType:     contentType,
+		payloadTemplate: tmpl,
+		httpClient:      &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+func (g *gateway) Send(ctx context.Context, to, text string) error {
+	payload := &bytes.Buffer{}
+	if err := g.payloadTemplate.Execute(payload, templateData{To: to, Text: text}); err != nil {
+		return fmt.Errorf("failed to render sms payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", g.contentType)
+	if g.authHeaderName != "" {
+		req.Header.Set(g.authHeaderName, g.authHeaderValue)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call sms gateway: %w", httpclient.StripUrl(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway responded with status %d: %s", resp.StatusCode, httpclient.ReadErrorBody(resp))
+	}
+	return nil
+}
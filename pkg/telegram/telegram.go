@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+type ClientConfig struct {
+	Token   string
+	BaseUrl string
+	Timeout time.Duration
+}
+
+type Client interface {
+	SendMessage(ctx context.Context, chatId, text string) error
+}
+
+type Update struct {
+	UpdateId int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+type Message struct {
+	MessageId int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type Chat struct {
+	Id int64 `json:"id"`
+}
+
+type sendMessageRequest struct {
+	ChatId string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+type apiResponse struct {
+	Ok          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+type client struct {
+	token      string
+	baseUrl    string
+	httpClient *http.Client
+}
+
+func NewClient(config *ClientConfig) *client {
+	return &client{
+		token:      config.Token,
+		baseUrl:    strings.TrimRight(config.BaseUrl, "/"),
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func (c *client) methodUrl(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", c.baseUrl, c.token, method)
+}
+
+func (c *client) SendMessage(ctx context.Context, chatId, text string) error {
+	payload, err := json.Marshal(sendMessageRequest{ChatId: chatId, Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodUrl("sendMessage"), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call telegram bot api: %w", httpclient.StripUrl(err))
+	}
+	defer resp.Body.Close()
+
+	var response apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode telegram bot api response, status %d", resp.StatusCode)
+	}
+	if !response.Ok {
+		return fmt.Errorf("telegram bot api error %d: %s", response.ErrorCode, response.Description)
+	}
+	return nil
+}
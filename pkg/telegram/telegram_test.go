@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:secret-token"
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(&ClientConfig{Token: testToken, BaseUrl: server.URL + "/", Timeout: time.Second})
+}
+
+func TestSendMessage(t *testing.T) {
+	var got sendMessageRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if want := "/bot" + testToken + "/sendMessage"; r.URL.Path != want {
+			t.Errorf("path = %s, want %s", r.URL.Path, want)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type = %s, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+
+	if err := c.SendMessage(context.Background(), "42", "happy birthday"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ChatId != "42" || got.Text != "happy birthday" {
+		t.Errorf("request = %+v, want chat 42 with the message text", got)
+	}
+}
+
+func TestSendMessageApiError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+	})
+
+	err := c.SendMessage(context.Background(), "42", "happy birthday")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "bot was blocked") {
+		t.Errorf("error = %q, want code and description of the api error", err)
+	}
+}
+
+func TestSendMessageInvalidResponse(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>bad gateway</html>"))
+	})
+
+	err := c.SendMessage(context.Background(), "42", "happy birthday")
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("error = %v, want error with the response status", err)
+	}
+}
+
+func TestSendMessageHidesToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	c := NewClient(&ClientConfig{Token: testToken, BaseUrl: server.URL, Timeout: time.Second})
+
+	err := c.SendMessage(context.Background(), "42", "happy birthday")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), testToken) {
+		t.Errorf("error %q contains bot token", err)
+	}
+}
@@ -36,6 +36,14 @@ func FormatErrors(errors validator.ValidationErrors) []FormattedError {
 			errorText = "must be email"
 		case "uuid4":
 			errorText = "must be UUIDv4"
+		case "url":
+			errorText = "must be URL"
+		case "e164":
+			errorText = "must be phone number in E.164 format"
+		case "unique":
+			errorText = "must contain unique values"
+		case "oneof":
+			errorText = fmt.Sprintf("must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
 		case "min":
 			if err.Kind() == reflect.Int {
 				errorText = fmt.Sprintf("should be greater than %s", err.Param())
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+var ErrInvalidUrl = errors.New("webhook url must be an absolute http or https url")
+
+type SenderConfig struct {
+	Timeout time.Duration
+	// AllowPrivateAddresses allows webhooks to internal addresses, it is meant for local development only
+	AllowPrivateAddresses bool
+}
+
+type Request struct {
+	Url        string
+	Secret     string
+	Event      string
+	DeliveryId string
+	Payload    []byte
+}
+
+type Sender interface {
+	Send(ctx context.Context, request Request) (int, error)
+	ValidateUrl(ctx context.Context, rawUrl string) error
+}
+
+type sender struct {
+	httpClient            *http.Client
+	allowPrivateAddresses bool
+}
+
+func NewSender(config *SenderConfig) *sender {
+	transport := httpclient.NewTransport(config.Timeout, config.AllowPrivateAddresses)
+	return &sender{
+		httpClient:            &http.Client{Timeout: config.Timeout, Transport: transport},
+		allowPrivateAddresses: config.AllowPrivateAddresses,
+	}
+}
+
+// ValidateUrl checks that the url can be used as a webhook target.
+// addresses are checked again on every request, since the host may resolve differently later
+func (s *sender) ValidateUrl(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidUrl
+	}
+	if s.allowPrivateAddresses {
+		return nil
+	}
+	return httpclient.CheckPublicHost(ctx, u.Hostname())
+}
+
+// Sign returns HMAC-SHA256 signature of the payload prefixed with the timestamp,
+// so receivers are able to reject replayed deliveries.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature produced by Sign in constant time.
+func Verify(secret string, timestamp int64, payload []byte, signature string) bool {
+	expected := Sign(secret, timestamp, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *sender) Send(ctx context.Context, request Request) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.Url, bytes.NewReader(request.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(request.Secret, timestamp, request.Payload))
+	req.Header.Set(EventHeader, request.Event)
+	req.Header.Set(DeliveryHeader, request.DeliveryId)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", httpclient.StripUrl(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody := httpclient.ReadErrorBody(resp)
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, respBody)
+	}
+	return resp.StatusCode, nil
+}
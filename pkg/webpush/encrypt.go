@@ -0,0 +1,140 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	saltLength      = 16
+	authLength      = 16
+	publicKeyLength = 65
+	keyLength       = 16
+	nonceLength     = 12
+	tagLength       = 16
+	recordSize      = 4096
+	// header = salt || rs || idlen || keyid, RFC 8188 section 2.1
+	headerLength = saltLength + 4 + 1 + publicKeyLength
+	// last record delimiter, RFC 8188 section 2
+	lastRecordDelimiter = 0x02
+)
+
+var (
+	ErrInvalidKeys     = errors.New("invalid subscription keys")
+	ErrPayloadTooLarge = errors.New("payload is too large")
+)
+
+// MaxPayloadLength is the size of plaintext fitting into a message of a single record,
+// push services are only required to accept 4096 bytes including the header, RFC 8291 section 4
+const MaxPayloadLength = recordSize - headerLength - tagLength - 1
+
+func decodeBase64(value string) ([]byte, error) {
+	// browsers use url safe alphabet without padding, but some libraries add it
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.URLEncoding.DecodeString(value)
+}
+
+func decodeKeys(p256dh, auth string) (*ecdh.PublicKey, []byte, error) {
+	publicKeyBytes, err := decodeBase64(p256dh)
+	if err != nil || len(publicKeyBytes) != publicKeyLength {
+		return nil, nil, ErrInvalidKeys
+	}
+	publicKey, err := ecdh.P256().NewPublicKey(publicKeyBytes)
+	if err != nil {
+		return nil, nil, ErrInvalidKeys
+	}
+	authSecret, err := decodeBase64(auth)
+	if err != nil || len(authSecret) != authLength {
+		return nil, nil, ErrInvalidKeys
+	}
+	return publicKey, authSecret, nil
+}
+
+// ValidateKeys checks keys of the browser PushSubscription.
+func ValidateKeys(p256dh, auth string) error {
+	_, _, err := decodeKeys(p256dh, auth)
+	return err
+}
+
+func hkdfRead(secret, salt, info []byte, length int) ([]byte, error) {
+	result := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Encrypt encrypts payload for the user agent as described in RFC 8291,
+// using aes128gcm content coding from RFC 8188 with a single record.
+func Encrypt(payload []byte, p256dh, auth string) ([]byte, error) {
+	if len(payload) > MaxPayloadLength {
+		return nil, ErrPayloadTooLarge
+	}
+	uaPublicKey, authSecret, err := decodeKeys(p256dh, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	asPrivateKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ecdhSecret, err := asPrivateKey.ECDH(uaPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	uaPublic := uaPublicKey.Bytes()
+	asPublic := asPrivateKey.PublicKey().Bytes()
+
+	// key_info = "WebPush: info" || 0x00 || ua_public || as_public
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdfRead(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := hkdfRead(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), keyLength)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfRead(ikm, salt, []byte("Content-Encoding: nonce\x00"), nonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := append(append([]byte{}, payload...), lastRecordDelimiter)
+
+	// header = salt || rs || idlen || keyid
+	header := make([]byte, 0, headerLength)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
@@ -0,0 +1,162 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+type userAgent struct {
+	privateKey *ecdh.PrivateKey
+	authSecret []byte
+}
+
+func newUserAgent(t *testing.T) *userAgent {
+	t.Helper()
+	privateKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, authLength)
+	if _, err := rand.Read(authSecret); err != nil {
+		t.Fatal(err)
+	}
+	return &userAgent{privateKey: privateKey, authSecret: authSecret}
+}
+
+func (ua *userAgent) keys() (string, string) {
+	return base64.RawURLEncoding.EncodeToString(ua.privateKey.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(ua.authSecret)
+}
+
+// decrypt does what the browser does with the received message, RFC 8291 section 3.4
+func (ua *userAgent) decrypt(t *testing.T, message []byte) []byte {
+	t.Helper()
+	if len(message) < headerLength {
+		t.Fatalf("message length = %d, shorter than header", len(message))
+	}
+	salt := message[:saltLength]
+	rs := binary.BigEndian.Uint32(message[saltLength : saltLength+4])
+	idLength := int(message[saltLength+4])
+	if rs != recordSize || idLength != publicKeyLength {
+		t.Fatalf("record size = %d, key id length = %d", rs, idLength)
+	}
+	asPublic := message[saltLength+5 : headerLength]
+	if len(message) > int(rs) {
+		t.Fatalf("message length = %d, exceeds record size", len(message))
+	}
+
+	asPublicKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhSecret, err := ua.privateKey.ECDH(asPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyInfo := append([]byte("WebPush: info\x00"), ua.privateKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdfRead(ecdhSecret, ua.authSecret, keyInfo, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contentKey, err := hkdfRead(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), keyLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := hkdfRead(ikm, salt, []byte("Content-Encoding: nonce\x00"), nonceLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, message[headerLength:], nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != lastRecordDelimiter {
+		t.Fatal("plaintext does not end with last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	ua := newUserAgent(t)
+	p256dh, auth := ua.keys()
+	payload := []byte(`{"title":"Birthday","body":"Today is John's birthday"}`)
+
+	message, err := Encrypt(payload, p256dh, auth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ua.decrypt(t, message); !bytes.Equal(got, payload) {
+		t.Errorf("decrypted = %q, want %q", got, payload)
+	}
+}
+
+func TestEncryptMaxPayloadLength(t *testing.T) {
+	if MaxPayloadLength != 3993 {
+		t.Errorf("MaxPayloadLength = %d, want 3993", MaxPayloadLength)
+	}
+
+	ua := newUserAgent(t)
+	p256dh, auth := ua.keys()
+
+	payload := bytes.Repeat([]byte("a"), MaxPayloadLength)
+	message, err := Encrypt(payload, p256dh, auth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(message) != recordSize {
+		t.Errorf("message length = %d, want %d", len(message), recordSize)
+	}
+	if got := ua.decrypt(t, message); !bytes.Equal(got, payload) {
+		t.Error("decrypted payload differs from the original")
+	}
+
+	_, err = Encrypt(append(payload, 'a'), p256dh, auth)
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("error = %v, want ErrPayloadTooLarge", err)
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	ua := newUserAgent(t)
+	p256dh, auth := ua.keys()
+
+	tests := []struct {
+		name   string
+		p256dh string
+		auth   string
+		valid  bool
+	}{
+		{"valid", p256dh, auth, true},
+		{"padded", p256dh + "=", base64.URLEncoding.EncodeToString(ua.authSecret), true},
+		{"invalid encoding", "not base64!", auth, false},
+		{"short auth", p256dh, base64.RawURLEncoding.EncodeToString([]byte("short")), false},
+		{"not a curve point", base64.RawURLEncoding.EncodeToString(make([]byte, publicKeyLength)), auth, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateKeys(test.p256dh, test.auth)
+			if test.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidKeys) {
+				t.Errorf("error = %v, want ErrInvalidKeys", err)
+			}
+		})
+	}
+}
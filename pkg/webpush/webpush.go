@@ -0,0 +1,165 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+const (
+	// vapid token lifetime, must not exceed 24 hours
+	vapidTokenTtl = 12 * time.Hour
+)
+
+// ErrSubscriptionGone is returned when push service reports that subscription
+// has expired or was unsubscribed, so it should not be used anymore.
+var ErrSubscriptionGone = errors.New("push subscription is gone")
+
+type ClientConfig struct {
+	VapidPublicKey  string
+	VapidPrivateKey string
+	Subject         string
+	Ttl             time.Duration
+	Timeout         time.Duration
+	// AllowPrivateAddresses allows endpoints on internal addresses, push services are always public,
+	// so it is meant for tests only
+	AllowPrivateAddresses bool
+}
+
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+type Client interface {
+	Send(ctx context.Context, subscription Subscription, payload []byte) error
+}
+
+type client struct {
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+	ttl        time.Duration
+	httpClient *http.Client
+}
+
+// GenerateVapidKeys returns new P-256 key pair encoded with base64url,
+// public key is in uncompressed form, as expected by browsers.
+func GenerateVapidKeys() (string, string, error) {
+	privateKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	publicKey := base64.RawURLEncoding.EncodeToString(privateKey.PublicKey().Bytes())
+	return publicKey, base64.RawURLEncoding.EncodeToString(privateKey.Bytes()), nil
+}
+
+func parsePrivateKey(publicKey, privateKey string) (*ecdsa.PrivateKey, error) {
+	privateKeyBytes, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, errors.New("invalid vapid private key encoding")
+	}
+	key, err := ecdh.P256().NewPrivateKey(privateKeyBytes)
+	if err != nil {
+		return nil, errors.New("invalid vapid private key")
+	}
+	publicKeyBytes := key.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(publicKeyBytes) != publicKey {
+		return nil, errors.New("vapid public key does not match private key")
+	}
+
+	// uncompressed point is 0x04 || x || y
+	coordinateLength := (len(publicKeyBytes) - 1) / 2
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(publicKeyBytes[1 : 1+coordinateLength]),
+			Y:     new(big.Int).SetBytes(publicKeyBytes[1+coordinateLength:]),
+		},
+		D: new(big.Int).SetBytes(privateKeyBytes),
+	}, nil
+}
+
+func NewClient(config *ClientConfig) (*client, error) {
+	privateKey, err := parsePrivateKey(config.VapidPublicKey, config.VapidPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		publicKey:  config.VapidPublicKey,
+		privateKey: privateKey,
+		subject:    config.Subject,
+		ttl:        config.Ttl,
+		httpClient: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: httpclient.NewTransport(config.Timeout, config.AllowPrivateAddresses),
+		},
+	}, nil
+}
+
+// vapidAuthorization builds authorization header described in RFC 8292.
+func (c *client) vapidAuthorization(endpoint string) (string, error) {
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"aud": fmt.Sprintf("%s://%s", endpointUrl.Scheme, endpointUrl.Host),
+		"exp": time.Now().Add(vapidTokenTtl).Unix(),
+		"sub": c.subject,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(c.privateKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", token, c.publicKey), nil
+}
+
+func (c *client) Send(ctx context.Context, subscription Subscription, payload []byte) error {
+	body, err := Encrypt(payload, subscription.P256dh, subscription.Auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.vapidAuthorization(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(c.ttl.Seconds())))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call push service: %w", httpclient.StripUrl(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound {
+		return ErrSubscriptionGone
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push service responded with status %d: %s", resp.StatusCode, httpclient.ReadErrorBody(resp))
+	}
+	return nil
+}
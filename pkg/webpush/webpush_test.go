@@ -0,0 +1,176 @@
+package webpush
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/vshevchenk0/bday-notifier/pkg/httpclient"
+)
+
+func newTestClient(t *testing.T) *client {
+	t.Helper()
+	publicKey, privateKey, err := GenerateVapidKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient(&ClientConfig{
+		VapidPublicKey:  publicKey,
+		VapidPrivateKey: privateKey,
+		Subject:         "mailto:admin@example.com",
+		Ttl:             time.Hour,
+		Timeout:         time.Second,
+		// fake push services listen on loopback
+		AllowPrivateAddresses: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNewClientMismatchedKeys(t *testing.T) {
+	publicKey, _, err := GenerateVapidKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, privateKey, err := GenerateVapidKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewClient(&ClientConfig{VapidPublicKey: publicKey, VapidPrivateKey: privateKey})
+	if err == nil {
+		t.Error("expected error for mismatched vapid keys")
+	}
+}
+
+func TestSend(t *testing.T) {
+	c := newTestClient(t)
+	ua := newUserAgent(t)
+	p256dh, auth := ua.keys()
+	payload := []byte(`{"title":"Birthday"}`)
+
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "aes128gcm" {
+			t.Errorf("content encoding = %s, want aes128gcm", r.Header.Get("Content-Encoding"))
+		}
+		if r.Header.Get("TTL") != "3600" {
+			t.Errorf("ttl = %s, want 3600", r.Header.Get("TTL"))
+		}
+		checkVapidAuthorization(t, c, r)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		received = body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	subscription := Subscription{Endpoint: server.URL + "/push/abc", P256dh: p256dh, Auth: auth}
+	if err := c.Send(context.Background(), subscription, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ua.decrypt(t, received); string(got) != string(payload) {
+		t.Errorf("push service received %q, want %q", got, payload)
+	}
+}
+
+// checkVapidAuthorization verifies the token the way push services do, RFC 8292 section 2
+func checkVapidAuthorization(t *testing.T, c *client, r *http.Request) {
+	t.Helper()
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "vapid t=") {
+		t.Errorf("authorization = %q, want vapid scheme", authorization)
+		return
+	}
+	token, key, found := strings.Cut(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+	if !found || key != c.publicKey {
+		t.Errorf("authorization = %q, want public key of the client", authorization)
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		return &c.privateKey.PublicKey, nil
+	})
+	if err != nil {
+		t.Errorf("invalid vapid token: %v", err)
+		return
+	}
+	if aud := "http://" + r.Host; claims["aud"] != aud {
+		t.Errorf("aud = %v, want %s", claims["aud"], aud)
+	}
+	if claims["sub"] != "mailto:admin@example.com" {
+		t.Errorf("sub = %v, want subject of the client", claims["sub"])
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr func(error) bool
+	}{
+		{"gone", http.StatusGone, func(err error) bool { return errors.Is(err, ErrSubscriptionGone) }},
+		{"not found", http.StatusNotFound, func(err error) bool { return errors.Is(err, ErrSubscriptionGone) }},
+		{"too many requests", http.StatusTooManyRequests, func(err error) bool {
+			return err != nil && !errors.Is(err, ErrSubscriptionGone) && strings.Contains(err.Error(), "429")
+		}},
+	}
+
+	c := newTestClient(t)
+	p256dh, auth := newUserAgent(t).keys()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			subscription := Subscription{Endpoint: server.URL, P256dh: p256dh, Auth: auth}
+			if err := c.Send(context.Background(), subscription, []byte("{}")); !test.wantErr(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSendRefusesPrivateAddress(t *testing.T) {
+	publicKey, privateKey, err := GenerateVapidKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient(&ClientConfig{
+		VapidPublicKey:  publicKey,
+		VapidPrivateKey: privateKey,
+		Subject:         "mailto:admin@example.com",
+		Timeout:         time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	p256dh, auth := newUserAgent(t).keys()
+	subscription := Subscription{Endpoint: server.URL, P256dh: p256dh, Auth: auth}
+	err = c.Send(context.Background(), subscription, []byte("{}"))
+	if !errors.Is(err, httpclient.ErrForbiddenAddress) {
+		t.Errorf("error = %v, want ErrForbiddenAddress", err)
+	}
+	if requested {
+		t.Error("request reached the loopback server")
+	}
+}